@@ -11,29 +11,82 @@ import (
 )
 
 // Encrypt copmutes a cipher from a plain text message.
+// An empty or nil message is valid and results in a single block of padding.
 func Encrypt(key []byte, plain []byte) ([]byte, error) {
-	keyLen := len(key)
-	if keyLen != 16 && keyLen != 24 && keyLen != 32 {
-		return nil, fmt.Errorf("encryption key must be either 16, 24 or 32 bytes long. Current key length: %v", keyLen)
+	if err := ValidateKey(key); err != nil {
+		return nil, err
 	}
+	return encrypt(key, plain)
+}
 
-	// Use PKCS7 padding algorithm to pad the plaintext message:
-	paddedPlain, err := pkcs7.Pad(plain, aes.BlockSize)
+// encrypt computes a cipher in CBC mode without checking the key for zero bytes.
+func encrypt(key []byte, plain []byte) ([]byte, error) {
+	// Generate a new block using the encryption key:
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return nil, fmt.Errorf("error when padding message with PKCS7: %w", err)
+		return nil, fmt.Errorf("error when creating new cipher: %w", err)
 	}
-	encryptedLen := len(paddedPlain)
 
-	// Generate a random IV which matches the block size in length:
-	ivLen := aes.BlockSize
-	iv := rng.GenerateBytes(ivLen)
+	return encryptCBC(block, plain)
+}
+
+// ErrKeySizeMismatch is returned when the length of a key doesn't match the requested cipher strength.
+var ErrKeySizeMismatch = errors.New("encryption key length doesn't match the requested key size")
+
+// EncryptWithKeySize computes a cipher like Encrypt, but first asserts that the key
+// has the length of the requested cipher strength of 128, 192 or 256 bits. Encrypt
+// picks AES-128, AES-192 or AES-256 from the key length alone, therefore a key which
+// was truncated by accident would silently downgrade the cipher.
+func EncryptWithKeySize(key []byte, plain []byte, bits int) ([]byte, error) {
+	if bits != 128 && bits != 192 && bits != 256 {
+		return nil, fmt.Errorf("key size must be either 128, 192 or 256 bits. Requested key size: %v", bits)
+	}
+	if len(key)*8 != bits {
+		return nil, fmt.Errorf("%w: expected %v bits, got %v bits", ErrKeySizeMismatch, bits, len(key)*8)
+	}
+	return Encrypt(key, plain)
+}
 
+// Decrypt reverts a cipher into its original plaintext message.
+// The cipher of an empty message is decrypted into an empty, non-nil slice.
+func Decrypt(key, scrambled []byte) ([]byte, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	return decrypt(key, scrambled)
+}
+
+// decrypt reverts a cipher in CBC mode without checking the key for zero bytes.
+func decrypt(key, scrambled []byte) ([]byte, error) {
 	// Generate a new block using the encryption key:
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, fmt.Errorf("error when creating new cipher: %w", err)
 	}
 
+	return decryptCBC(block, scrambled)
+}
+
+// encryptCBC encrypts a plain text message in CBC mode with PKCS7 padding.
+// Padding and IV length are derived from the block size of the given cipher.
+func encryptCBC(block cipher.Block, plain []byte) ([]byte, error) {
+	// Use PKCS7 padding algorithm to pad the plaintext message:
+	paddedPlain, err := pkcs7.Pad(plain, block.BlockSize())
+	if err != nil {
+		return nil, fmt.Errorf("error when padding message with PKCS7: %w", err)
+	}
+	return sealCBC(block, paddedPlain), nil
+}
+
+// sealCBC encrypts a message whose length is a multiple of the block size in CBC mode
+// and prepends a random IV. It doesn't pad, which is left to the caller.
+func sealCBC(block cipher.Block, paddedPlain []byte) []byte {
+	encryptedLen := len(paddedPlain)
+
+	// Generate a random IV which matches the block size in length:
+	ivLen := block.BlockSize()
+	iv := rng.GenerateBytes(ivLen)
+
 	// Encrypt the padded message using CBC mode:
 	mode := cipher.NewCBCEncrypter(block, iv)
 	encrypted := make([]byte, encryptedLen)
@@ -44,37 +97,42 @@ func Encrypt(key []byte, plain []byte) ([]byte, error) {
 	copy(result[:ivLen], iv)
 	copy(result[ivLen:], encrypted)
 
-	return result, nil
+	return result
 }
 
-// Decrypt reverts a cipher into its original plaintext message.
-func Decrypt(key, scrambled []byte) ([]byte, error) {
-	keyLen := len(key)
-	if keyLen != 16 && keyLen != 24 && keyLen != 32 {
-		return nil, errors.New("encryption key must be either 16, 24 or 32 bytes long")
-	}
-
-	ivLen := aes.BlockSize
-	iv := scrambled[:ivLen]
-	encryptedBytes := scrambled[ivLen:]
-	encryptedBytesLen := len(encryptedBytes)
-
-	// Generate a new block using the encryption key:
-	block, err := aes.NewCipher(key)
+// decryptCBC reverts a cipher from encryptCBC into its original plaintext message.
+func decryptCBC(block cipher.Block, scrambled []byte) ([]byte, error) {
+	paddedPlain, err := openCBC(block, scrambled)
 	if err != nil {
-		return nil, fmt.Errorf("error when creating new cipher: %w", err)
+		return nil, err
 	}
 
-	// Decrypt the encrypted message using CBC mode:
-	mode := cipher.NewCBCDecrypter(block, iv)
-	paddedPlain := make([]byte, encryptedBytesLen)
-	mode.CryptBlocks(paddedPlain, encryptedBytes)
-
 	// Unpad the message
-	plain, err := pkcs7.Unpad(paddedPlain, aes.BlockSize)
+	plain, err := pkcs7.Unpad(paddedPlain, block.BlockSize())
 	if err != nil {
 		return nil, fmt.Errorf("error when un-padding message with PKCS7: %w", err)
 	}
 
 	return plain, nil
 }
+
+// openCBC reverts a cipher from sealCBC into the still padded message.
+func openCBC(block cipher.Block, scrambled []byte) ([]byte, error) {
+	blockSize := block.BlockSize()
+
+	// The cipher consists of the IV and at least one block, because even
+	// an empty plaintext message is padded to a full block:
+	ivLen := blockSize
+	if len(scrambled) < ivLen+blockSize || len(scrambled)%blockSize != 0 {
+		return nil, fmt.Errorf("cipher must be a multiple of %d bytes and at least %d bytes long", blockSize, ivLen+blockSize)
+	}
+	iv := scrambled[:ivLen]
+	encryptedBytes := scrambled[ivLen:]
+
+	// Decrypt the encrypted message using CBC mode:
+	mode := cipher.NewCBCDecrypter(block, iv)
+	paddedPlain := make([]byte, len(encryptedBytes))
+	mode.CryptBlocks(paddedPlain, encryptedBytes)
+
+	return paddedPlain, nil
+}
@@ -2,7 +2,13 @@ package aes
 
 import (
 	"bytes"
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/des" // nolint: gosec
+	"errors"
 	"testing"
+
+	"github.com/dusted-go/security/rng"
 )
 
 func Test_EncryptAndDecrypt_ReturnsInitialMessage(t *testing.T) {
@@ -55,3 +61,129 @@ func Test_Decrypt_WithEncryptedMessageFromDotnet_ReturnsCorrectPlainMessage(t *t
 		t.Error("Expected:", expected, "Actual:", string(plain))
 	}
 }
+
+func Test_EncryptAndDecrypt_WithEmptyMessage_ReturnsEmptyMessage(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+
+	for _, plain := range [][]byte{nil, {}} {
+		cipher, err := Encrypt(key, plain)
+		if err != nil {
+			t.Error("Error when encrypting message.")
+		}
+
+		// IV and a single block which consists of padding only
+		if len(cipher) != 32 {
+			t.Error("Expected length:", 32, "Actual length:", len(cipher))
+		}
+
+		plain2, err := Decrypt(key, cipher)
+		if err != nil {
+			t.Error("Error when decrypting message.")
+		}
+
+		if plain2 == nil || len(plain2) != 0 {
+			t.Error("Expected an empty message. Actual:", plain2)
+		}
+	}
+}
+
+func Test_Decrypt_WithTooShortCipher_ReturnsError(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+
+	for _, cipher := range [][]byte{nil, make([]byte, 16), make([]byte, 40)} {
+		if _, err := Decrypt(key, cipher); err == nil {
+			t.Error("Decrypt was expected to return an error for a cipher of length:", len(cipher))
+		}
+	}
+}
+
+func Test_Decrypt_WithOutOfRangePadding_ReturnsError(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+	block, _ := stdaes.NewCipher(key)
+
+	// Padding lengths of zero or beyond the block size must not be trusted
+	for _, padLen := range []byte{0, 17, 48, 255} {
+		paddedPlain := bytes.Repeat([]byte{padLen}, 16)
+		scrambled := make([]byte, 32)
+		cipher.NewCBCEncrypter(block, scrambled[:16]).CryptBlocks(scrambled[16:], paddedPlain)
+
+		if _, err := Decrypt(key, scrambled); err == nil {
+			t.Error("Decrypt was expected to return an error for a padding length of:", padLen)
+		}
+	}
+}
+
+func Test_encryptCBCAndDecryptCBC_WithEightByteBlockCipher_ReturnsInitialMessage(t *testing.T) {
+	block, err := des.NewCipher([]byte{1, 2, 3, 4, 5, 6, 7, 8}) // nolint: gosec
+	if err != nil {
+		t.Error("Error when creating cipher.")
+	}
+	plain := []byte("The world is flat, but don't tell anyone.")
+
+	cipher, err := encryptCBC(block, plain)
+	if err != nil {
+		t.Error("Error when encrypting message.")
+	}
+
+	// IV, message and padding are aligned to 8 byte blocks
+	if len(cipher) != 8+48 {
+		t.Error("Expected length:", 8+48, "Actual length:", len(cipher))
+	}
+
+	plain2, err := decryptCBC(block, cipher)
+	if err != nil {
+		t.Error("Error when decrypting message.")
+	}
+
+	if !bytes.Equal(plain, plain2) {
+		t.Error("Expected:", plain, "Actual:", string(plain2))
+	}
+}
+
+func Test_EncryptWithKeySize_WithMatchingKeySize_ReturnsDecryptableCipher(t *testing.T) {
+	for _, bits := range []int{128, 192, 256} {
+		key := rng.GenerateBytes(bits / 8)
+
+		scrambled, err := EncryptWithKeySize(key, []byte("message"), bits)
+		if err != nil {
+			t.Error("EncryptWithKeySize returned an unexpected error: " + err.Error())
+		}
+		plain, err := Decrypt(key, scrambled)
+		if err != nil {
+			t.Error("Decrypt returned an unexpected error: " + err.Error())
+		}
+		if string(plain) != "message" {
+			t.Error("Expected:", "message", "Actual:", string(plain))
+		}
+	}
+}
+
+func Test_EncryptWithKeySize_WithMismatchingKeySize_ReturnsError(t *testing.T) {
+	cases := []struct {
+		keyLen int
+		bits   int
+	}{
+		{16, 256},
+		{24, 256},
+		{16, 192},
+		{32, 128},
+	}
+	for _, c := range cases {
+		_, err := EncryptWithKeySize(rng.GenerateBytes(c.keyLen), []byte("message"), c.bits)
+		if !errors.Is(err, ErrKeySizeMismatch) {
+			t.Error("Expected:", ErrKeySizeMismatch, "Actual:", err)
+		}
+	}
+}
+
+func Test_EncryptWithKeySize_WithUnsupportedKeySize_ReturnsError(t *testing.T) {
+	if _, err := EncryptWithKeySize(rng.GenerateBytes(8), []byte("message"), 64); err == nil {
+		t.Error("EncryptWithKeySize was expected to return an error for a 64 bit key size.")
+	}
+}
@@ -0,0 +1,60 @@
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/dusted-go/security/sig"
+)
+
+// ErrAuthenticationFailed is returned by DecryptAuthenticated for every cipher which
+// cannot be decrypted, so that a tampered cipher is indistinguishable from a padding error.
+var ErrAuthenticationFailed = errors.New("message authentication failed")
+
+// EncryptAuthenticated computes a cipher like Encrypt and appends an HMAC-SHA256 tag
+// over the IV and the cipher (encrypt-then-MAC). The MAC key must be independent
+// of the encryption key, e.g. derived with kdf.DeriveKey under a different label.
+// Unlike Encrypt it protects against tampering and padding oracle attacks.
+func EncryptAuthenticated(encryptionKey, macKey, plain []byte) ([]byte, error) {
+	if len(macKey) == 0 {
+		return nil, errors.New("MAC key cannot be empty")
+	}
+	scrambled, err := Encrypt(encryptionKey, plain)
+	if err != nil {
+		return nil, err
+	}
+	tag := sig.ComputeSHA256(macKey, scrambled)
+	return append(scrambled, tag...), nil
+}
+
+// DecryptAuthenticated verifies the tag of a cipher from EncryptAuthenticated before
+// decrypting it. It returns ErrAuthenticationFailed for an invalid tag, length or padding.
+func DecryptAuthenticated(encryptionKey, macKey, authenticated []byte) ([]byte, error) {
+	if len(macKey) == 0 {
+		return nil, errors.New("MAC key cannot be empty")
+	}
+	if err := ValidateKey(encryptionKey); err != nil {
+		return nil, err
+	}
+
+	// 1. Split the tag from the end of the cipher
+	if len(authenticated) < aes.BlockSize+sha256.Size {
+		return nil, ErrAuthenticationFailed
+	}
+	scrambled := authenticated[:len(authenticated)-sha256.Size]
+	tag := authenticated[len(authenticated)-sha256.Size:]
+
+	// 2. Verify the tag before anything else
+	if !sig.ValidateSHA256(macKey, scrambled, tag) {
+		return nil, ErrAuthenticationFailed
+	}
+
+	// 3. Decrypt the authenticated cipher. It only fails for a cipher which was
+	// authenticated with a compromised MAC key, which must not learn the reason.
+	plain, err := Decrypt(encryptionKey, scrambled)
+	if err != nil {
+		return nil, ErrAuthenticationFailed
+	}
+	return plain, nil
+}
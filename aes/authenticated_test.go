@@ -0,0 +1,73 @@
+package aes
+
+import (
+	"bytes"
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/dusted-go/security/sig"
+)
+
+func Test_EncryptAuthenticatedAndDecryptAuthenticated_ReturnsInitialMessage(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167,
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+	macKey := []byte("some-stupid-mac-key")
+	plain := []byte("The world is flat, but don't tell anyone.")
+
+	authenticated, err := EncryptAuthenticated(key, macKey, plain)
+	if err != nil {
+		t.Error("Error when encrypting message.")
+	}
+
+	plain2, err := DecryptAuthenticated(key, macKey, authenticated)
+	if err != nil {
+		t.Error("Error when decrypting message.")
+	}
+
+	if !bytes.Equal(plain, plain2) {
+		t.Error("Expected:", plain, "Actual:", string(plain2))
+	}
+}
+
+func Test_DecryptAuthenticated_WithTamperedCipherOrBadPadding_ReturnsGenericError(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167,
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+	macKey := []byte("some-stupid-mac-key")
+	plain := []byte("The world is flat, but don't tell anyone.")
+
+	authenticated, err := EncryptAuthenticated(key, macKey, plain)
+	if err != nil {
+		t.Error("Error when encrypting message.")
+	}
+
+	// Flip a bit of the IV, which would alter the first plaintext block in CBC mode
+	tampered := append([]byte{}, authenticated...)
+	tampered[0] ^= 1
+
+	// Validly tagged cipher with an invalid padding byte of zero
+	block, _ := stdaes.NewCipher(key)
+	iv := make([]byte, stdaes.BlockSize)
+	badPadding := make([]byte, stdaes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(badPadding, make([]byte, stdaes.BlockSize))
+	badPadding = append(iv, badPadding...)
+	badPadding = append(badPadding, sig.ComputeSHA256(macKey, badPadding)...)
+
+	for name, c := range map[string][]byte{
+		"tampered":    tampered,
+		"truncated":   authenticated[:len(authenticated)-1],
+		"short":       authenticated[:10],
+		"bad padding": badPadding,
+	} {
+		_, err := DecryptAuthenticated(key, macKey, c)
+		if err != ErrAuthenticationFailed {
+			t.Error("Expected:", ErrAuthenticationFailed, "Actual:", err, "Cipher:", name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+)
+
+// Cipher encrypts and decrypts messages with a fixed key.
+// Block modes which require padding apply it themselves,
+// whereas AEAD modes encrypt the message without any padding.
+type Cipher interface {
+	Encrypt(plain []byte) ([]byte, error)
+	Decrypt(scrambled []byte) ([]byte, error)
+}
+
+// cbcCipher is a Cipher in CBC mode with PKCS7 padding, equivalent to Encrypt and Decrypt.
+type cbcCipher struct {
+	block cipher.Block
+}
+
+func (c *cbcCipher) Encrypt(plain []byte) ([]byte, error) {
+	return encryptCBC(c.block, plain)
+}
+
+func (c *cbcCipher) Decrypt(scrambled []byte) ([]byte, error) {
+	return decryptCBC(c.block, scrambled)
+}
+
+// NewCBCCipher creates a Cipher which encrypts in CBC mode with PKCS7 padding
+// like Encrypt and Decrypt.
+func NewCBCCipher(key []byte) (Cipher, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error when creating new cipher: %w", err)
+	}
+	return &cbcCipher{block: block}, nil
+}
+
+// gcmCipher is a Cipher in GCM mode without additional data, equivalent to EncryptGCM and DecryptGCM.
+type gcmCipher struct {
+	gcm cipher.AEAD
+}
+
+func (c *gcmCipher) Encrypt(plain []byte) ([]byte, error) {
+	return sealGCM(c.gcm, plain, nil), nil
+}
+
+func (c *gcmCipher) Decrypt(scrambled []byte) ([]byte, error) {
+	return openGCM(c.gcm, scrambled, nil)
+}
+
+// NewGCMCipher creates a Cipher which encrypts in GCM mode like EncryptGCM and
+// DecryptGCM without additional data. GCM doesn't pad, therefore a cipher is
+// exactly as long as the nonce, the plain text message and the tag.
+func NewGCMCipher(key []byte) (Cipher, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &gcmCipher{gcm: gcm}, nil
+}
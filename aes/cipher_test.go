@@ -0,0 +1,62 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_NewGCMCipher_Encrypt_ReturnsCipherWithoutPadding(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+	nonceLen, tagLen := 12, 16
+
+	c, err := NewGCMCipher(key)
+	if err != nil {
+		t.Error("NewGCMCipher returned an unexpected error: " + err.Error())
+		return
+	}
+
+	for _, plain := range [][]byte{{}, []byte("message"), bytes.Repeat([]byte{1}, 16)} {
+		scrambled, err := c.Encrypt(plain)
+		if err != nil {
+			t.Error("Encrypt returned an unexpected error: " + err.Error())
+		}
+		if expected := nonceLen + len(plain) + tagLen; len(scrambled) != expected {
+			t.Error("Expected length:", expected, "Actual length:", len(scrambled))
+		}
+
+		decrypted, err := c.Decrypt(scrambled)
+		if err != nil {
+			t.Error("Decrypt returned an unexpected error: " + err.Error())
+		}
+		if !bytes.Equal(plain, decrypted) {
+			t.Error("Expected:", plain, "Actual:", decrypted)
+		}
+	}
+}
+
+func Test_NewCBCCipher_Encrypt_ReturnsPaddedCipher(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+
+	c, err := NewCBCCipher(key)
+	if err != nil {
+		t.Error("NewCBCCipher returned an unexpected error: " + err.Error())
+		return
+	}
+
+	scrambled, err := c.Encrypt([]byte("message"))
+	if err != nil {
+		t.Error("Encrypt returned an unexpected error: " + err.Error())
+	}
+	if len(scrambled) != 32 {
+		t.Error("Expected length:", 32, "Actual length:", len(scrambled))
+	}
+
+	plain, err := Decrypt(key, scrambled)
+	if err != nil || string(plain) != "message" {
+		t.Error("Cipher was expected to be compatible with Decrypt. Error:", err)
+	}
+}
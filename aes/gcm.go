@@ -0,0 +1,67 @@
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+
+	"github.com/dusted-go/security/rng"
+)
+
+// newGCM creates an AES-GCM AEAD for the given key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error when creating new cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error when creating GCM mode: %w", err)
+	}
+	return gcm, nil
+}
+
+// EncryptGCM computes an authenticated cipher from a plain text message using AES-GCM.
+// The additional data (e.g. a record ID) is authenticated but not encrypted and must
+// be passed to DecryptGCM again, so a cipher cannot be moved into another context.
+func EncryptGCM(key, plain, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return sealGCM(gcm, plain, additionalData), nil
+}
+
+// sealGCM encrypts a message and prepends a random nonce to the cipher.
+func sealGCM(gcm cipher.AEAD, plain, additionalData []byte) []byte {
+	nonce := rng.GenerateBytes(gcm.NonceSize())
+	return gcm.Seal(nonce, nonce, plain, additionalData)
+}
+
+// DecryptGCM authenticates and reverts a cipher from EncryptGCM into its original plaintext message.
+func DecryptGCM(key, scrambled, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return openGCM(gcm, scrambled, additionalData)
+}
+
+// openGCM authenticates and decrypts a cipher from sealGCM.
+func openGCM(gcm cipher.AEAD, scrambled, additionalData []byte) ([]byte, error) {
+	nonceLen := gcm.NonceSize()
+	if len(scrambled) < nonceLen+gcm.Overhead() {
+		return nil, fmt.Errorf("cipher must be at least %d bytes long", nonceLen+gcm.Overhead())
+	}
+
+	plain, err := gcm.Open(nil, scrambled[:nonceLen], scrambled[nonceLen:], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("error when authenticating cipher: %w", err)
+	}
+	return plain, nil
+}
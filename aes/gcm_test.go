@@ -0,0 +1,47 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_EncryptGCMAndDecryptGCM_ReturnsInitialMessage(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167,
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+	plain := []byte("The world is flat, but don't tell anyone.")
+
+	cipher, err := EncryptGCM(key, plain, []byte("row1"))
+	if err != nil {
+		t.Error("Error when encrypting message.")
+	}
+
+	plain2, err := DecryptGCM(key, cipher, []byte("row1"))
+	if err != nil {
+		t.Error("Error when decrypting message.")
+	}
+
+	if !bytes.Equal(plain, plain2) {
+		t.Error("Expected:", plain, "Actual:", string(plain2))
+	}
+}
+
+func Test_DecryptGCM_WithDifferentAdditionalData_ReturnsError(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167,
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+	plain := []byte("The world is flat, but don't tell anyone.")
+
+	cipher, err := EncryptGCM(key, plain, []byte("row1"))
+	if err != nil {
+		t.Error("Error when encrypting message.")
+	}
+
+	if _, err := DecryptGCM(key, cipher, []byte("row2")); err == nil {
+		t.Error("DecryptGCM was expected to reject a cipher moved to a different row.")
+	}
+}
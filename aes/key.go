@@ -0,0 +1,66 @@
+package aes
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrZeroKey is returned when an encryption key consists of zero bytes only,
+// which most likely is an uninitialised key rather than a deliberate choice.
+// Only CBC ciphers can be computed with such a key, using UnsafeEncryptWithZeroKey
+// and UnsafeDecryptWithZeroKey. All other functions of this package always reject it.
+var ErrZeroKey = errors.New("encryption key must not consist of zero bytes only")
+
+// checkZeroKey returns ErrZeroKey if all bytes of the key are zero.
+// The key is always inspected in full.
+func checkZeroKey(key []byte) error {
+	var acc byte
+	for _, b := range key {
+		acc |= b
+	}
+	if acc == 0 {
+		return ErrZeroKey
+	}
+	return nil
+}
+
+// checkKeyLength returns an error if a key is not 16, 24 or 32 bytes long.
+func checkKeyLength(key []byte) error {
+	keyLen := len(key)
+	if keyLen != 16 && keyLen != 24 && keyLen != 32 {
+		return fmt.Errorf("encryption key must be either 16, 24 or 32 bytes long. Current key length: %v", keyLen)
+	}
+	return nil
+}
+
+// ValidateKey verifies that a key can be used with Encrypt, Decrypt, EncryptGCM
+// and DecryptGCM: it must be 16, 24 or 32 bytes long and not consist of zero
+// bytes only.
+func ValidateKey(key []byte) error {
+	if err := checkKeyLength(key); err != nil {
+		return err
+	}
+	return checkZeroKey(key)
+}
+
+// UnsafeEncryptWithZeroKey computes a cipher like Encrypt, but also accepts a key
+// which consists of zero bytes only. Any key of a valid length is technically a
+// valid AES key, therefore it exists for interoperability with a system which used
+// such a key. It must not be used with new keys. There is no such opt-out for GCM,
+// SIV, the Cipher implementations or the streaming functions.
+func UnsafeEncryptWithZeroKey(key []byte, plain []byte) ([]byte, error) {
+	if err := checkKeyLength(key); err != nil {
+		return nil, err
+	}
+	return encrypt(key, plain)
+}
+
+// UnsafeDecryptWithZeroKey reverts a cipher like Decrypt, but also accepts a key
+// which consists of zero bytes only, e.g. to decrypt ciphers of a system which
+// used such a key. It must not be used with new keys.
+func UnsafeDecryptWithZeroKey(key, scrambled []byte) ([]byte, error) {
+	if err := checkKeyLength(key); err != nil {
+		return nil, err
+	}
+	return decrypt(key, scrambled)
+}
@@ -0,0 +1,56 @@
+package aes
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_Encrypt_WithZeroKey_ReturnsErrZeroKey(t *testing.T) {
+	key := make([]byte, 32)
+
+	if _, err := Encrypt(key, []byte("message")); !errors.Is(err, ErrZeroKey) {
+		t.Error("Expected:", ErrZeroKey, "Actual:", err)
+	}
+	if _, err := EncryptGCM(key, []byte("message"), nil); !errors.Is(err, ErrZeroKey) {
+		t.Error("Expected:", ErrZeroKey, "Actual:", err)
+	}
+	if _, err := EncryptSIV(make([]byte, 64), []byte("message"), nil); !errors.Is(err, ErrZeroKey) {
+		t.Error("Expected:", ErrZeroKey, "Actual:", err)
+	}
+	if err := ValidateKey(key); !errors.Is(err, ErrZeroKey) {
+		t.Error("Expected:", ErrZeroKey, "Actual:", err)
+	}
+}
+
+func Test_UnsafeEncryptAndUnsafeDecrypt_WithZeroKey_ReturnsInitialMessage(t *testing.T) {
+	key := make([]byte, 32)
+
+	scrambled, err := UnsafeEncryptWithZeroKey(key, []byte("message"))
+	if err != nil {
+		t.Error("UnsafeEncryptWithZeroKey returned an unexpected error: " + err.Error())
+	}
+	plain, err := UnsafeDecryptWithZeroKey(key, scrambled)
+	if err != nil {
+		t.Error("UnsafeDecryptWithZeroKey returned an unexpected error: " + err.Error())
+	}
+	if string(plain) != "message" {
+		t.Error("Expected:", "message", "Actual:", string(plain))
+	}
+
+	// The guard of the safe functions is unaffected
+	if _, err := Decrypt(key, scrambled); !errors.Is(err, ErrZeroKey) {
+		t.Error("Expected:", ErrZeroKey, "Actual:", err)
+	}
+}
+
+func Test_UnsafeEncryptWithZeroKey_WithWrongLength_ReturnsError(t *testing.T) {
+	if _, err := UnsafeEncryptWithZeroKey(make([]byte, 3), []byte("message")); err == nil {
+		t.Error("UnsafeEncryptWithZeroKey was expected to return an error for a 3 byte key.")
+	}
+}
+
+func Test_ValidateKey_WithWrongLength_ReturnsError(t *testing.T) {
+	if err := ValidateKey([]byte{1, 2, 3}); err == nil {
+		t.Error("ValidateKey was expected to return an error for a 3 byte key.")
+	}
+}
@@ -0,0 +1,159 @@
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+)
+
+// This file implements AES-SIV as specified in RFC 5297.
+
+// dbl multiplies a block by x in GF(2^128) as specified in RFC 5297.
+func dbl(block []byte) []byte {
+	result := make([]byte, aes.BlockSize)
+	carry := block[0] >> 7
+	for i := 0; i < aes.BlockSize-1; i++ {
+		result[i] = block[i]<<1 | block[i+1]>>7
+	}
+	result[aes.BlockSize-1] = block[aes.BlockSize-1] << 1
+	// Constant-time conditional XOR with the reduction polynomial:
+	result[aes.BlockSize-1] ^= byte(subtle.ConstantTimeSelect(int(carry), 0x87, 0))
+	return result
+}
+
+// xorBytes xors src into dst.
+func xorBytes(dst, src []byte) {
+	for i := range src {
+		dst[i] ^= src[i]
+	}
+}
+
+// cmac computes AES-CMAC as specified in RFC 4493.
+func cmac(block cipher.Block, msg []byte) []byte {
+	l := make([]byte, aes.BlockSize)
+	block.Encrypt(l, l)
+	k1 := dbl(l)
+	k2 := dbl(k1)
+
+	// Split the message into full blocks and a (possibly padded) last block:
+	n := (len(msg) + aes.BlockSize - 1) / aes.BlockSize
+	complete := n > 0 && len(msg)%aes.BlockSize == 0
+	if n == 0 {
+		n = 1
+	}
+	last := make([]byte, aes.BlockSize)
+	copy(last, msg[(n-1)*aes.BlockSize:])
+	if complete {
+		xorBytes(last, k1)
+	} else {
+		last[len(msg)-(n-1)*aes.BlockSize] = 0x80
+		xorBytes(last, k2)
+	}
+
+	x := make([]byte, aes.BlockSize)
+	for i := 0; i < n-1; i++ {
+		xorBytes(x, msg[i*aes.BlockSize:(i+1)*aes.BlockSize])
+		block.Encrypt(x, x)
+	}
+	xorBytes(x, last)
+	block.Encrypt(x, x)
+	return x
+}
+
+// s2v computes the synthetic IV of a list of strings as specified in RFC 5297.
+func s2v(block cipher.Block, inputs ...[]byte) []byte {
+	d := cmac(block, make([]byte, aes.BlockSize))
+	for _, s := range inputs[:len(inputs)-1] {
+		d = dbl(d)
+		xorBytes(d, cmac(block, s))
+	}
+
+	sn := inputs[len(inputs)-1]
+	var t []byte
+	if len(sn) >= aes.BlockSize {
+		t = make([]byte, len(sn))
+		copy(t, sn)
+		xorBytes(t[len(sn)-aes.BlockSize:], d)
+	} else {
+		t = dbl(d)
+		padded := make([]byte, aes.BlockSize)
+		copy(padded, sn)
+		padded[len(sn)] = 0x80
+		xorBytes(t, padded)
+	}
+	return cmac(block, t)
+}
+
+// newSIV splits a SIV key into the S2V block and the CTR block.
+func newSIV(key []byte) (macBlock cipher.Block, ctrBlock cipher.Block, err error) {
+	keyLen := len(key)
+	if keyLen != 32 && keyLen != 48 && keyLen != 64 {
+		return nil, nil, fmt.Errorf("encryption key must be either 32, 48 or 64 bytes long. Current key length: %v", keyLen)
+	}
+	if err := checkZeroKey(key); err != nil {
+		return nil, nil, err
+	}
+
+	macBlock, err = aes.NewCipher(key[:keyLen/2])
+	if err != nil {
+		return nil, nil, fmt.Errorf("error when creating new cipher: %w", err)
+	}
+	ctrBlock, err = aes.NewCipher(key[keyLen/2:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("error when creating new cipher: %w", err)
+	}
+	return macBlock, ctrBlock, nil
+}
+
+// ctr applies AES-CTR keyed with the synthetic IV to a message.
+func ctr(block cipher.Block, v, msg []byte) []byte {
+	// Clear the 31st and 63rd bit (from the right) of the counter:
+	q := make([]byte, aes.BlockSize)
+	copy(q, v)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+
+	result := make([]byte, len(msg))
+	cipher.NewCTR(block, q).XORKeyStream(result, msg)
+	return result
+}
+
+// EncryptSIV computes a cipher from a plain text message using AES-SIV (RFC 5297).
+//
+// Unlike Encrypt and EncryptGCM, AES-SIV is DETERMINISTIC: the same key, plain text
+// and additional data always produce the same cipher. This is intended for use cases
+// like encrypted lookup keys, but reveals when two messages are equal.
+// The additional data is authenticated but not encrypted and must be passed to DecryptSIV again.
+// The key is split into two halves and must be 32, 48 or 64 bytes long.
+func EncryptSIV(key, plain, additionalData []byte) ([]byte, error) {
+	macBlock, ctrBlock, err := newSIV(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepend the synthetic IV to the cipher:
+	v := s2v(macBlock, additionalData, plain)
+	return append(v, ctr(ctrBlock, v, plain)...), nil
+}
+
+// DecryptSIV authenticates and reverts a cipher from EncryptSIV into its original plaintext message.
+func DecryptSIV(key, scrambled, additionalData []byte) ([]byte, error) {
+	macBlock, ctrBlock, err := newSIV(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scrambled) < aes.BlockSize {
+		return nil, fmt.Errorf("cipher must be at least %d bytes long", aes.BlockSize)
+	}
+
+	v := scrambled[:aes.BlockSize]
+	plain := ctr(ctrBlock, v, scrambled[aes.BlockSize:])
+
+	if subtle.ConstantTimeCompare(v, s2v(macBlock, additionalData, plain)) != 1 {
+		return nil, errors.New("error when authenticating cipher: message authentication failed")
+	}
+	return plain, nil
+}
@@ -0,0 +1,64 @@
+package aes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func Test_EncryptSIV_WithRFC5297TestVector_ReturnsCorrectCipher(t *testing.T) {
+	key, _ := hex.DecodeString("fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	additionalData, _ := hex.DecodeString("101112131415161718191a1b1c1d1e1f2021222324252627")
+	plain, _ := hex.DecodeString("112233445566778899aabbccddee")
+	expected, _ := hex.DecodeString("85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c")
+
+	actual, err := EncryptSIV(key, plain, additionalData)
+	if err != nil {
+		t.Error("Error when encrypting message.")
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Error("Expected:", expected, "Actual:", actual)
+	}
+
+	plain2, err := DecryptSIV(key, actual, additionalData)
+	if err != nil {
+		t.Error("Error when decrypting message.")
+	}
+
+	if !bytes.Equal(plain, plain2) {
+		t.Error("Expected:", plain, "Actual:", plain2)
+	}
+}
+
+func Test_EncryptSIV_WithSameInput_ReturnsSameCipher(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 64)
+	plain := []byte("The world is flat, but don't tell anyone.")
+
+	cipher1, err := EncryptSIV(key, plain, nil)
+	if err != nil {
+		t.Error("Error when encrypting message.")
+	}
+	cipher2, err := EncryptSIV(key, plain, nil)
+	if err != nil {
+		t.Error("Error when encrypting message.")
+	}
+
+	if !bytes.Equal(cipher1, cipher2) {
+		t.Error("AES-SIV was expected to be deterministic.")
+	}
+}
+
+func Test_DecryptSIV_WithDifferentAdditionalData_ReturnsError(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	plain := []byte("The world is flat, but don't tell anyone.")
+
+	cipher, err := EncryptSIV(key, plain, []byte("row1"))
+	if err != nil {
+		t.Error("Error when encrypting message.")
+	}
+
+	if _, err := DecryptSIV(key, cipher, []byte("row2")); err == nil {
+		t.Error("DecryptSIV was expected to reject a cipher moved to a different row.")
+	}
+}
@@ -0,0 +1,52 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"io"
+
+	"github.com/dusted-go/security/rng"
+)
+
+// newCTR creates an AES-CTR stream for the given key and IV.
+func newCTR(key, iv []byte) (cipher.Stream, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error when creating new cipher: %w", err)
+	}
+	return cipher.NewCTR(block, iv), nil
+}
+
+// EncryptReader returns a reader which yields a random IV followed by the data of src
+// encrypted with AES-CTR, so that encryption composes with io.Copy and HTTP bodies.
+// CTR mode is not authenticated: sign the cipher (e.g. with sig.NewWriter) if it
+// must be protected against tampering.
+func EncryptReader(key []byte, src io.Reader) (io.Reader, error) {
+	iv := rng.GenerateBytes(aes.BlockSize)
+	stream, err := newCTR(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return io.MultiReader(
+		bytes.NewReader(iv),
+		&cipher.StreamReader{S: stream, R: src}), nil
+}
+
+// DecryptReader returns a reader which yields the decrypted data of a cipher from EncryptReader.
+// The IV is read from src immediately.
+func DecryptReader(key []byte, src io.Reader) (io.Reader, error) {
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(src, iv); err != nil {
+		return nil, fmt.Errorf("error when reading IV of cipher: %w", err)
+	}
+	stream, err := newCTR(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &cipher.StreamReader{S: stream, R: src}, nil
+}
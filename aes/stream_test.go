@@ -0,0 +1,60 @@
+package aes
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func Test_EncryptReaderAndDecryptReader_ReturnsInitialData(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+	data := bytes.Repeat([]byte("streamed data "), 1000)
+
+	encrypted, err := EncryptReader(key, bytes.NewReader(data))
+	if err != nil {
+		t.Error("EncryptReader returned an unexpected error: " + err.Error())
+		return
+	}
+	decrypted, err := DecryptReader(key, encrypted)
+	if err != nil {
+		t.Error("DecryptReader returned an unexpected error: " + err.Error())
+		return
+	}
+
+	var actual bytes.Buffer
+	if _, err := io.Copy(&actual, decrypted); err != nil {
+		t.Error("io.Copy returned an unexpected error: " + err.Error())
+	}
+	if !bytes.Equal(data, actual.Bytes()) {
+		t.Error("Decrypted stream was expected to equal the initial data.")
+	}
+}
+
+func Test_EncryptReader_PrependsIVAndDoesNotPad(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+	data := []byte("message")
+
+	encrypted, _ := EncryptReader(key, bytes.NewReader(data))
+	scrambled, err := io.ReadAll(encrypted)
+
+	if err != nil {
+		t.Error("Reading the cipher returned an unexpected error: " + err.Error())
+	}
+	if len(scrambled) != 16+len(data) {
+		t.Error("Expected length:", 16+len(data), "Actual length:", len(scrambled))
+	}
+}
+
+func Test_DecryptReader_WithShortCipher_ReturnsError(t *testing.T) {
+	key := []byte{
+		96, 245, 15, 133, 99, 15, 153, 159,
+		49, 74, 43, 238, 216, 14, 67, 167}
+
+	if _, err := DecryptReader(key, bytes.NewReader(make([]byte, 15))); err == nil {
+		t.Error("DecryptReader was expected to return an error for a cipher without a full IV.")
+	}
+}
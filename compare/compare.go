@@ -1,5 +1,10 @@
 package compare
 
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
 // Hashes validates two hashes in a secure way which
 // will prevent timing attacks by always iterating
 // through the entire byte array.
@@ -16,3 +21,12 @@ func Hashes(hash1 []byte, hash2 []byte) bool {
 
 	return equal
 }
+
+// Secrets compares two secrets of possibly different lengths, e.g. a user
+// supplied API key against a stored one. Both secrets are hashed with SHA-256
+// first, so that the time taken doesn't reveal the length of either secret.
+func Secrets(a []byte, b []byte) bool {
+	hashA := sha256.Sum256(a)
+	hashB := sha256.Sum256(b)
+	return subtle.ConstantTimeCompare(hashA[:], hashB[:]) == 1
+}
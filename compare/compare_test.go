@@ -19,3 +19,22 @@ func Test_Compare_WithUnequalHashes_ReturnsFalse(t *testing.T) {
 		t.Error("Compare didn't recognise two different byte arrays as unequal.")
 	}
 }
+
+func Test_Secrets_WithEqualSecrets_ReturnsTrue(t *testing.T) {
+	secret1 := []byte("my-api-key")
+	secret2 := []byte("my-api-key")
+
+	if !Secrets(secret1, secret2) {
+		t.Error("Secrets didn't recognise two identical secrets as equal.")
+	}
+}
+
+func Test_Secrets_WithDifferentLengths_ReturnsFalse(t *testing.T) {
+	for _, secret := range [][]byte{nil, []byte("my-api"), []byte("my-api-key-"), []byte("my-api-key\x00")} {
+		for i := 0; i < 3; i++ {
+			if Secrets([]byte("my-api-key"), secret) {
+				t.Error("Secrets didn't recognise secrets of different lengths as unequal:", secret)
+			}
+		}
+	}
+}
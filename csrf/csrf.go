@@ -0,0 +1,72 @@
+package csrf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+
+	"github.com/dusted-go/security/compare"
+	"github.com/dusted-go/security/rng"
+	"github.com/dusted-go/security/sig"
+)
+
+// Length of the random nonce which makes every token unique.
+const nonceLength = 16
+
+// Length of the big-endian unix expiry date.
+const expiryLength = 8
+
+// Length of the HMAC-SHA256 signature.
+const signatureLength = 32
+
+// signedMessage returns the bytes which are signed in a token.
+// The session ID comes last, so it needs no length prefix.
+func signedMessage(payload []byte, sessionID string) []byte {
+	msg := make([]byte, 0, len(payload)+len(sessionID))
+	msg = append(msg, payload...)
+	return append(msg, sessionID...)
+}
+
+// Generate creates a stateless CSRF token which is bound to the session and
+// expires after ttl. The token is base64url encoded and consists of the expiry
+// date, a random nonce and an HMAC-SHA256 signature over both and the session ID,
+// so it must not be stored server-side. Every call returns a different token.
+func Generate(key []byte, sessionID string, ttl time.Duration) string {
+	if key == nil {
+		panic("key cannot be nil.")
+	}
+
+	// 1. Concatenate the expiry date and a random nonce
+	payload := make([]byte, expiryLength, expiryLength+nonceLength)
+	binary.BigEndian.PutUint64(payload, uint64(time.Now().Add(ttl).Unix()))
+	payload = append(payload, rng.GenerateBytes(nonceLength)...)
+
+	// 2. Sign the payload together with the session ID
+	signature := sig.ComputeSHA256(key, signedMessage(payload, sessionID))
+
+	return base64.RawURLEncoding.EncodeToString(append(payload, signature...))
+}
+
+// Validate verifies that a CSRF token from Generate was signed with the key,
+// is bound to the session and has not expired.
+// The signature is compared in constant time.
+func Validate(key []byte, sessionID, token string) bool {
+	if key == nil {
+		panic("key cannot be nil.")
+	}
+
+	// 1. Decode the token and split it into payload and signature
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != expiryLength+nonceLength+signatureLength {
+		return false
+	}
+	payload, signature := raw[:expiryLength+nonceLength], raw[expiryLength+nonceLength:]
+
+	// 2. Verify the signature and the expiry date
+	expected := sig.ComputeSHA256(key, signedMessage(payload, sessionID))
+	validSignature := compare.Hashes(expected, signature)
+	expiry := time.Unix(int64(binary.BigEndian.Uint64(payload[:expiryLength])), 0)
+	notExpired := time.Now().Before(expiry)
+
+	return validSignature && notExpired
+}
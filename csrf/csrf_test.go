@@ -0,0 +1,49 @@
+package csrf
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Validate_WithValidToken_ReturnsTrue(t *testing.T) {
+	key := []byte("some-stupid-csrf-key")
+	token := Generate(key, "session-1", time.Hour)
+
+	if !Validate(key, "session-1", token) {
+		t.Error("Token was expected to be valid:", token)
+	}
+}
+
+func Test_Generate_ReturnsDifferentTokens(t *testing.T) {
+	key := []byte("some-stupid-csrf-key")
+
+	if Generate(key, "session-1", time.Hour) == Generate(key, "session-1", time.Hour) {
+		t.Error("Tokens were expected to differ.")
+	}
+}
+
+func Test_Validate_WithExpiredToken_ReturnsFalse(t *testing.T) {
+	key := []byte("some-stupid-csrf-key")
+	token := Generate(key, "session-1", -time.Second)
+
+	if Validate(key, "session-1", token) {
+		t.Error("Expired token was expected to be invalid:", token)
+	}
+}
+
+func Test_Validate_WithDifferentSessionOrKey_ReturnsFalse(t *testing.T) {
+	key := []byte("some-stupid-csrf-key")
+	token := Generate(key, "session-1", time.Hour)
+
+	if Validate(key, "session-2", token) {
+		t.Error("Token of a different session was expected to be invalid.")
+	}
+	if Validate([]byte("another-csrf-key"), "session-1", token) {
+		t.Error("Token signed with a different key was expected to be invalid.")
+	}
+	for _, malformed := range []string{"", "not base64!", token[:len(token)-2]} {
+		if Validate(key, "session-1", malformed) {
+			t.Error("Malformed token was expected to be invalid:", malformed)
+		}
+	}
+}
@@ -5,4 +5,7 @@ go 1.20
 require (
 	github.com/dusted-go/encoding v1.0.0
 	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
+	golang.org/x/text v0.14.0
 )
+
+require golang.org/x/sys v0.5.0 // indirect
@@ -0,0 +1,24 @@
+package kdf
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// DeriveKey derives a key of the given length from a master secret using
+// HKDF with SHA-256. Different info labels yield independent keys from the
+// same master secret and salt.
+func DeriveKey(master, salt []byte, info string, length int) []byte {
+	if master == nil {
+		panic("master cannot be nil")
+	}
+	key := make([]byte, length)
+	_, err := io.ReadFull(hkdf.New(sha256.New, master, salt, []byte(info)), key)
+	if err != nil {
+		panic(fmt.Errorf("failed to derive a key of %d bytes: %w", length, err))
+	}
+	return key
+}
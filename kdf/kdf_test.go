@@ -0,0 +1,50 @@
+package kdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_DeriveKey_WithSameInput_ReturnsSameKey(t *testing.T) {
+	master := []byte("some-stupid-master-secret")
+
+	key1 := DeriveKey(master, nil, "encryption", 32)
+	key2 := DeriveKey(master, nil, "encryption", 32)
+
+	if !bytes.Equal(key1, key2) {
+		t.Error("Derived keys were expected to be equal.")
+	}
+	if len(key1) != 32 {
+		t.Error("Expected length:", 32, "Actual length:", len(key1))
+	}
+}
+
+func Test_DeriveKey_WithDifferentInfo_ReturnsDifferentKeys(t *testing.T) {
+	master := []byte("some-stupid-master-secret")
+
+	key1 := DeriveKey(master, nil, "encryption", 32)
+	key2 := DeriveKey(master, nil, "signing", 32)
+
+	if bytes.Equal(key1, key2) {
+		t.Error("Derived keys were expected to differ.")
+	}
+}
+
+func Test_DeriveKey_WithRFC5869TestCase1_ReturnsCorrectKey(t *testing.T) {
+	master := bytes.Repeat([]byte{0x0b}, 22)
+	salt := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	info := string([]byte{0xf0, 0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8, 0xf9})
+	expected := []byte{
+		0x3c, 0xb2, 0x5f, 0x25, 0xfa, 0xac, 0xd5, 0x7a,
+		0x90, 0x43, 0x4f, 0x64, 0xd0, 0x36, 0x2f, 0x2a,
+		0x2d, 0x2d, 0x0a, 0x90, 0xcf, 0x1a, 0x5a, 0x4c,
+		0x5d, 0xb0, 0x2d, 0x56, 0xec, 0xc4, 0xc5, 0xbf,
+		0x34, 0x00, 0x72, 0x08, 0xd5, 0xb8, 0x87, 0x18,
+		0x58, 0x65}
+
+	actual := DeriveKey(master, salt, info, len(expected))
+
+	if !bytes.Equal(expected, actual) {
+		t.Error("Expected:", expected, "Actual:", actual)
+	}
+}
@@ -0,0 +1,155 @@
+package otp
+
+import (
+	"crypto/sha1" // nolint: gosec // SHA-1 is mandated by RFC 4226 and RFC 6238
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dusted-go/security/compare"
+	"github.com/dusted-go/security/rng"
+	"github.com/dusted-go/security/sig"
+)
+
+// DefaultDigits is the number of digits used by authenticator apps.
+const DefaultDigits = 6
+
+// Period is the time step of a TOTP code as recommended by RFC 6238.
+const Period = 30 * time.Second
+
+// Length of a generated secret in bytes as recommended by RFC 4226.
+const secretLength = 20
+
+// Encoding used for secrets shared with authenticator apps.
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret generates a new random base32 encoded secret.
+func GenerateSecret() string {
+	return rng.GenerateBase32Secret(secretLength)
+}
+
+// decodeSecret decodes a base32 encoded secret.
+// Spaces, padding and lowercase letters are tolerated as commonly typed by users.
+func decodeSecret(secret string) ([]byte, error) {
+	normalised := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	normalised = strings.TrimRight(normalised, "=")
+	key, err := secretEncoding.DecodeString(normalised)
+	if err != nil {
+		return nil, fmt.Errorf("secret must be base32 encoded: %w", err)
+	}
+	return key, nil
+}
+
+// generateCode computes a one-time password for a given hashing function,
+// key and counter using the dynamic truncation from RFC 4226.
+func generateCode(hasher sig.HashFactory, key []byte, counter uint64, digits int) string {
+	if digits < 1 || digits > 10 {
+		panic(fmt.Sprintf("digits must be between 1 and 10, got %d", digits))
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := sig.Compute(hasher, key, msg)
+
+	offset := mac[len(mac)-1] & 0x0f
+	binCode := uint64(binary.BigEndian.Uint32(mac[offset:offset+4]) & 0x7fffffff)
+
+	modulo := uint64(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, binCode%modulo)
+}
+
+// HOTP calculates an HMAC-SHA1 counter-based one-time password as specified in RFC 4226.
+func HOTP(secret []byte, counter uint64, digits int) string {
+	return generateCode(sha1.New, secret, counter, digits)
+}
+
+// ValidateHOTP verifies an HMAC-SHA1 counter-based one-time password with the given
+// number of digits, e.g. DefaultDigits. It panics if digits is not between 1 and 10.
+// Codes for up to lookAhead counters after the given counter are accepted as
+// well to resynchronise with tokens which have been used without a login.
+// On success newCounter is the counter which must be used for the next validation,
+// otherwise the given counter is returned unchanged.
+func ValidateHOTP(secret []byte, code string, counter uint64, lookAhead int, digits int) (ok bool, newCounter uint64) {
+	newCounter = counter
+	if lookAhead < 0 {
+		return false, newCounter
+	}
+	for i := uint64(0); i <= uint64(lookAhead); i++ {
+		expected := HOTP(secret, counter+i, digits)
+		if compare.Hashes([]byte(expected), []byte(code)) && !ok {
+			ok = true
+			newCounter = counter + i + 1
+		}
+	}
+	return ok, newCounter
+}
+
+// timeStep returns the RFC 6238 time counter for a given point in time.
+func timeStep(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(Period/time.Second))
+}
+
+// ComputeTOTP calculates a time-based one-time password for a given hashing
+// function, key, point in time and number of digits.
+func ComputeTOTP(hasher sig.HashFactory, key []byte, t time.Time, digits int) string {
+	return generateCode(hasher, key, timeStep(t), digits)
+}
+
+// TOTP calculates a 6 digit HMAC-SHA1 time-based one-time password for a base32 encoded secret.
+func TOTP(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return ComputeTOTP(sha1.New, key, t, DefaultDigits), nil
+}
+
+// ValidateTOTP verifies an HMAC-SHA1 time-based one-time password with the given
+// number of digits, e.g. DefaultDigits. It panics if digits is not between 1 and 10.
+// Codes from up to skew time steps before or after t are accepted as well
+// to allow for clock drift between server and client.
+func ValidateTOTP(secret, code string, t time.Time, skew int, digits int) bool {
+	if skew < 0 {
+		return false
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return false
+	}
+
+	ok := false
+	step := int64(timeStep(t))
+	for i := -int64(skew); i <= int64(skew); i++ {
+		if step+i < 0 {
+			continue
+		}
+		expected := generateCode(sha1.New, key, uint64(step+i), digits)
+		ok = compare.Hashes([]byte(expected), []byte(code)) || ok
+	}
+	return ok
+}
+
+// escapeURIComponent escapes an issuer or account name for an otpauth:// URI.
+// Spaces are encoded as %20, because not all authenticator apps decode a plus sign.
+func escapeURIComponent(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// ProvisioningURI builds the otpauth://totp/ URI which authenticator apps
+// read from an enrolment QR code. The period is given in seconds.
+func ProvisioningURI(issuer, account, secret string, digits int, period int) string {
+	return fmt.Sprintf(
+		"otpauth://totp/%s:%s?secret=%s&issuer=%s&algorithm=SHA1&digits=%d&period=%d",
+		escapeURIComponent(issuer),
+		escapeURIComponent(account),
+		escapeURIComponent(secret),
+		escapeURIComponent(issuer),
+		digits,
+		period)
+}
@@ -0,0 +1,196 @@
+package otp
+
+import (
+	"crypto/sha1" // nolint: gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+	"time"
+
+	"github.com/dusted-go/security/sig"
+)
+
+func Test_ComputeTOTP_WithRFC6238TestVectors_ReturnsCorrectCodes(t *testing.T) {
+	keys := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	hashers := map[string]sig.HashFactory{
+		"SHA1":   sha1.New,
+		"SHA256": sha256.New,
+		"SHA512": sha512.New,
+	}
+	vectors := []struct {
+		unix     int64
+		algo     string
+		expected string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1111111111, "SHA1", "14050471"},
+		{1111111111, "SHA256", "67062674"},
+		{1111111111, "SHA512", "99943326"},
+		{1234567890, "SHA1", "89005924"},
+		{1234567890, "SHA256", "91819424"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{2000000000, "SHA256", "90698825"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+		{20000000000, "SHA256", "77737706"},
+		{20000000000, "SHA512", "47863826"},
+	}
+
+	for _, v := range vectors {
+		actual := ComputeTOTP(hashers[v.algo], keys[v.algo], time.Unix(v.unix, 0), 8)
+		if actual != v.expected {
+			t.Error("Time:", v.unix, "Algorithm:", v.algo, "Expected:", v.expected, "Actual:", actual)
+		}
+	}
+}
+
+func Test_TOTP_WithBase32Secret_ReturnsSixDigitCode(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	expected := "287082"
+
+	actual, err := TOTP(secret, time.Unix(59, 0))
+
+	if err != nil {
+		t.Error("TOTP returned an unexpected error: " + err.Error())
+	}
+	if actual != expected {
+		t.Error("Expected:", expected, "Actual:", actual)
+	}
+}
+
+func Test_TOTP_WithInvalidSecret_ReturnsError(t *testing.T) {
+	if _, err := TOTP("not base32!", time.Now()); err == nil {
+		t.Error("TOTP was expected to return an error.")
+	}
+}
+
+func Test_ValidateTOTP_WithinSkew_ReturnsTrue(t *testing.T) {
+	secret := GenerateSecret()
+	now := time.Unix(1234567890, 0)
+	code, _ := TOTP(secret, now.Add(-Period))
+
+	if !ValidateTOTP(secret, code, now, 1, DefaultDigits) {
+		t.Error("Code from the previous time step was expected to be accepted with a skew of 1.")
+	}
+	if ValidateTOTP(secret, code, now, 0, DefaultDigits) {
+		t.Error("Code from the previous time step was expected to be rejected with a skew of 0.")
+	}
+}
+
+func Test_GenerateSecret_ReturnsDifferentSecrets(t *testing.T) {
+	secret1 := GenerateSecret()
+	secret2 := GenerateSecret()
+
+	if secret1 == secret2 {
+		t.Error("Randomly generated secrets were expected to differ.")
+	}
+	if len(secret1) != 32 {
+		t.Error("Expected length:", 32, "Actual length:", len(secret1))
+	}
+}
+
+func Test_HOTP_WithRFC4226TestVectors_ReturnsCorrectCodes(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range expected {
+		actual := HOTP(secret, uint64(counter), 6)
+		if actual != code {
+			t.Error("Counter:", counter, "Expected:", code, "Actual:", actual)
+		}
+	}
+}
+
+func Test_ValidateHOTP_WithEightDigitRFC4226TestVectors_ReturnsTrue(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	// Last 8 digits of the truncated values from RFC 4226 Appendix D
+	expected := []string{
+		"84755224", "94287082", "37359152", "26969429", "40338314",
+		"68254676", "18287922", "82162583", "73399871", "45520489",
+	}
+
+	for counter, code := range expected {
+		ok, newCounter := ValidateHOTP(secret, code, uint64(counter), 0, 8)
+		if !ok {
+			t.Error("Counter:", counter, "Code:", code, "was expected to be accepted with 8 digits.")
+		}
+		if newCounter != uint64(counter)+1 {
+			t.Error("Expected:", counter+1, "Actual:", newCounter)
+		}
+		if ok, _ := ValidateHOTP(secret, code[2:], uint64(counter), 0, 8); ok {
+			t.Error("Counter:", counter, "6 digit code was expected to be rejected with 8 digits.")
+		}
+	}
+}
+
+func Test_ValidateTOTP_WithEightDigitCode_ReturnsTrue(t *testing.T) {
+	// RFC 6238 SHA1 test vector
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	now := time.Unix(59, 0)
+
+	if !ValidateTOTP(secret, "94287082", now, 0, 8) {
+		t.Error("8 digit code was expected to be accepted with 8 digits.")
+	}
+	if ValidateTOTP(secret, "287082", now, 0, 8) {
+		t.Error("6 digit code was expected to be rejected with 8 digits.")
+	}
+}
+
+func Test_ValidateHOTP_WithinLookAhead_ReturnsAdvancedCounter(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	ok, newCounter := ValidateHOTP(secret, "969429", 1, 2, DefaultDigits)
+
+	if !ok {
+		t.Error("Code for counter 3 was expected to be accepted with a look-ahead of 2.")
+	}
+	if newCounter != 4 {
+		t.Error("Expected:", 4, "Actual:", newCounter)
+	}
+}
+
+func Test_ValidateHOTP_BeyondLookAhead_ReturnsSameCounter(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	ok, newCounter := ValidateHOTP(secret, "338314", 1, 2, DefaultDigits)
+
+	if ok {
+		t.Error("Code for counter 4 was expected to be rejected with a look-ahead of 2.")
+	}
+	if newCounter != 1 {
+		t.Error("Expected:", 1, "Actual:", newCounter)
+	}
+}
+
+func Test_ProvisioningURI_WithFixedSecretAndLabel_ReturnsKnownURI(t *testing.T) {
+	expected := "otpauth://totp/Example:alice%40google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example&algorithm=SHA1&digits=6&period=30"
+
+	actual := ProvisioningURI("Example", "alice@google.com", "JBSWY3DPEHPK3PXP", DefaultDigits, 30)
+
+	if expected != actual {
+		t.Error("Expected:", expected, "Actual:", actual)
+	}
+}
+
+func Test_ProvisioningURI_WithSpacesAndColons_EscapesLabel(t *testing.T) {
+	expected := "otpauth://totp/ACME%20Co%3A%20EU:john%3Adoe?secret=JBSWY3DPEHPK3PXP&issuer=ACME%20Co%3A%20EU&algorithm=SHA1&digits=8&period=60"
+
+	actual := ProvisioningURI("ACME Co: EU", "john:doe", "JBSWY3DPEHPK3PXP", 8, 60)
+
+	if expected != actual {
+		t.Error("Expected:", expected, "Actual:", actual)
+	}
+}
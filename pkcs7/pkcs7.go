@@ -6,9 +6,13 @@ import (
 	"fmt"
 )
 
+// Largest block size whose padding length still fits into a single byte.
+const maxBlockSize = 255
+
 // Pad adds padding to data.
+// The data is copied, so the caller's slice is never modified.
 func Pad(data []byte, blockSize int) ([]byte, error) {
-	if blockSize < 1 {
+	if blockSize < 1 || blockSize > maxBlockSize {
 		return nil, fmt.Errorf("pkcs7: Invalid block size %d", blockSize)
 	}
 	// Calculate the padding length
@@ -25,13 +29,15 @@ func Pad(data []byte, blockSize int) ([]byte, error) {
 	// Repeat
 	padding = bytes.Repeat(padding, padLen)
 
-	// Append the padding to the data
-	return append(data, padding...), nil
+	// Append the padding to a copy of the data
+	padded := make([]byte, 0, len(data)+padLen)
+	padded = append(padded, data...)
+	return append(padded, padding...), nil
 }
 
 // Unpad removes padding from data.
 func Unpad(data []byte, blockSize int) ([]byte, error) {
-	if blockSize < 1 {
+	if blockSize < 1 || blockSize > maxBlockSize {
 		return nil, fmt.Errorf("pkcs7: Invalid block size %d", blockSize)
 	}
 	if len(data)%blockSize != 0 || len(data) == 0 {
@@ -40,6 +46,9 @@ func Unpad(data []byte, blockSize int) ([]byte, error) {
 
 	// The last byte is the length of padding.
 	padLen := int(data[len(data)-1])
+	if padLen == 0 || padLen > blockSize || padLen > len(data) {
+		return nil, errors.New("pkcs7: Invalid padding")
+	}
 
 	// Check padding integrity.
 	// All bytes should be the same.
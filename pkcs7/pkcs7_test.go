@@ -0,0 +1,108 @@
+package pkcs7
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_Pad_WithEightByteBlockSize_ReturnsPaddedData(t *testing.T) {
+	data := []byte{1, 2, 3}
+	expected := []byte{1, 2, 3, 5, 5, 5, 5, 5}
+
+	actual, err := Pad(data, 8)
+
+	if err != nil {
+		t.Error("Pad returned an unexpected error: " + err.Error())
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Error("Expected:", expected, "Actual:", actual)
+	}
+}
+
+func Test_Pad_WithFullEightByteBlock_AppendsFullBlock(t *testing.T) {
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	expected := []byte{1, 2, 3, 4, 5, 6, 7, 8, 8, 8, 8, 8, 8, 8, 8, 8}
+
+	actual, err := Pad(data, 8)
+
+	if err != nil {
+		t.Error("Pad returned an unexpected error: " + err.Error())
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Error("Expected:", expected, "Actual:", actual)
+	}
+}
+
+func Test_Unpad_WithEightByteBlockSize_ReturnsInitialData(t *testing.T) {
+	data := []byte{1, 2, 3, 5, 5, 5, 5, 5}
+	expected := []byte{1, 2, 3}
+
+	actual, err := Unpad(data, 8)
+
+	if err != nil {
+		t.Error("Unpad returned an unexpected error: " + err.Error())
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Error("Expected:", expected, "Actual:", actual)
+	}
+}
+
+func Test_Pad_WithSpareCapacity_DoesNotModifyData(t *testing.T) {
+	backing := []byte{1, 2, 3, 9, 9, 9, 9, 9}
+	data := backing[:3]
+
+	_, err := Pad(data, 8)
+
+	if err != nil {
+		t.Error("Pad returned an unexpected error: " + err.Error())
+	}
+	if !bytes.Equal([]byte{1, 2, 3, 9, 9, 9, 9, 9}, backing) {
+		t.Error("Pad was not expected to modify the backing array. Actual:", backing)
+	}
+}
+
+func Test_PadAndUnpad_WithBlockSizeAboveByteRange_ReturnsError(t *testing.T) {
+	if _, err := Pad([]byte{1}, 256); err == nil {
+		t.Error("Pad was expected to return an error for a block size of 256.")
+	}
+	if _, err := Unpad(make([]byte, 256), 256); err == nil {
+		t.Error("Unpad was expected to return an error for a block size of 256.")
+	}
+}
+
+func Test_Unpad_WithOutOfRangePadding_ReturnsError(t *testing.T) {
+	for _, data := range [][]byte{
+		{1, 2, 3, 4, 5, 6, 7, 0},
+		{1, 2, 3, 4, 5, 6, 7, 9},
+		{1, 2, 3, 4, 5, 6, 7, 48},
+		{1, 2, 3, 4, 5, 6, 7, 255},
+	} {
+		if _, err := Unpad(data, 8); err == nil {
+			t.Error("Unpad was expected to return an error for data:", data)
+		}
+	}
+}
+
+func FuzzPkcs7Unpad(f *testing.F) {
+	f.Add([]byte{1, 2, 3, 5, 5, 5, 5, 5}, 8)
+	f.Add([]byte{1, 2, 3, 4, 5, 6, 7, 48}, 8)
+	f.Add([]byte{0}, 1)
+	f.Add([]byte{}, 16)
+	f.Add([]byte{16}, -1)
+
+	f.Fuzz(func(t *testing.T, data []byte, blockSize int) {
+		plain, err := Unpad(data, blockSize)
+		if err != nil {
+			return
+		}
+
+		// Valid padding must round-trip
+		padded, err := Pad(plain, blockSize)
+		if err != nil {
+			t.Fatal("Pad returned an unexpected error: " + err.Error())
+		}
+		if !bytes.Equal(data, padded) {
+			t.Error("Expected:", data, "Actual:", padded)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package pwd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Upper bounds of Argon2id parameters. Strategies can come from untrusted
+// hash strings and must not exhaust memory or CPU.
+const (
+	maxArgon2MemoryKiB  = 1 << 20
+	maxArgon2Iterations = 1 << 12
+	maxArgon2Threads    = 255
+	maxArgon2HashLength = 1024
+)
+
+// Parameters of an Argon2id strategy.
+type argon2Params struct {
+	memoryKiB  int
+	iterations int
+	threads    int
+	hashLength int
+}
+
+// Argon2idStrategy builds an Argon2id strategy string from the memory in KiB,
+// the number of iterations, the degree of parallelism and the hash length in bytes.
+func Argon2idStrategy(memoryKiB, iterations, threads, hashLength int) string {
+	return fmt.Sprintf(
+		"argon2id/%s/%s/%s/%s",
+		encodeBase62(memoryKiB),
+		encodeBase62(iterations),
+		encodeBase62(threads),
+		encodeBase62(hashLength))
+}
+
+// checkArgon2Params validates Argon2id parameters against the limits of the algorithm.
+func checkArgon2Params(params *argon2Params) error {
+	switch {
+	case params.iterations <= 0 || params.iterations > maxArgon2Iterations:
+		return fmt.Errorf("iterations must be between 1 and %d", maxArgon2Iterations)
+	case params.threads <= 0 || params.threads > maxArgon2Threads:
+		return fmt.Errorf("threads must be between 1 and %d", maxArgon2Threads)
+	case params.memoryKiB < 8*params.threads || params.memoryKiB > maxArgon2MemoryKiB:
+		return fmt.Errorf("memory must be between 8 KiB per thread and %d KiB", maxArgon2MemoryKiB)
+	case params.hashLength < 4 || params.hashLength > maxArgon2HashLength:
+		return fmt.Errorf("hash length must be between 4 and %d bytes", maxArgon2HashLength)
+	}
+	return nil
+}
+
+// Parses and validates the parameters of an Argon2id strategy.
+func parseArgon2Strategy(strategy string) (*argon2Params, error) {
+	errInvalidStrategy := errors.New("invalid strategy, cannot create Argon2id hashing function")
+
+	// Argon2id has 5 required parameters:
+	// 1. Identifier string (argon2id)
+	// 2. The memory in KiB
+	// 3. The number of iterations
+	// 4. The degree of parallelism
+	// 5. The length of the resulting hash
+	expectedArgs := 5
+	args := strings.SplitN(strategy, "/", expectedArgs)
+	if len(args) != expectedArgs || args[0] != "argon2id" {
+		return nil, errInvalidStrategy
+	}
+
+	var values [4]int
+	for i, arg := range args[1:] {
+		value, err := decodeBase62(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidStrategy, err)
+		}
+		values[i] = value
+	}
+
+	params := &argon2Params{
+		memoryKiB:  values[0],
+		iterations: values[1],
+		threads:    values[2],
+		hashLength: values[3]}
+	if err := checkArgon2Params(params); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidStrategy, err)
+	}
+	return params, nil
+}
+
+// Factory method to create the Argon2id key derivation function.
+func createArgon2idFn(strategy string) (hashFunc, error) {
+	params, err := parseArgon2Strategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	memoryKiB := uint32(params.memoryKiB)
+	iterations := uint32(params.iterations)
+	threads := uint8(params.threads)
+	hashLength := uint32(params.hashLength)
+
+	computeHash := func(password []byte, salt []byte) []byte {
+		return argon2.IDKey(
+			password,
+			salt,
+			iterations,
+			memoryKiB,
+			threads,
+			hashLength)
+	}
+	return computeHash, nil
+}
@@ -0,0 +1,186 @@
+package pwd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dusted-go/security/rng"
+
+	"github.com/dusted-go/encoding/base62"
+)
+
+// Upper bound of PBKDF2 iterations to stop calibration on very slow targets
+// and to reject strategies of untrusted hash strings which would never finish.
+const maxPbkdf2Iterations = 1 << 30
+
+// Upper bound of the PBKDF2 hash length in bytes.
+const maxPbkdf2HashLength = 1024
+
+// Alphabet used to base62 encode strategy parameters.
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// encodeBase62 encodes a non-negative integer into its base62 representation.
+func encodeBase62(n int) string {
+	if n == 0 {
+		return string(base62Alphabet[0])
+	}
+	var encoded []byte
+	for n > 0 {
+		encoded = append([]byte{base62Alphabet[n%62]}, encoded...)
+		n /= 62
+	}
+	return string(encoded)
+}
+
+// Maximum number of digits of a base62 encoded strategy parameter,
+// which keeps the decoded value far below the overflow of an int.
+const maxBase62Digits = 10
+
+// decodeBase62 decodes a base62 encoded non-negative integer. Unlike base62.DecodeToInt
+// it returns an error for an empty string, too many digits or characters outside
+// of the alphabet instead of decoding them into an arbitrary value.
+func decodeBase62(encoded string) (int, error) {
+	if encoded == "" || len(encoded) > maxBase62Digits {
+		return 0, fmt.Errorf("%q is not a valid base62 number", encoded)
+	}
+	for _, r := range encoded {
+		if !strings.ContainsRune(base62Alphabet, r) {
+			return 0, fmt.Errorf("%q is not a valid base62 number", encoded)
+		}
+	}
+	return base62.DecodeToInt(encoded), nil
+}
+
+// pbkdf2Strategy builds a PBKDF2 HMAC-SHA256 strategy string.
+func pbkdf2Strategy(hashLength, iterations int) string {
+	return fmt.Sprintf(
+		"pbkdf2/hmacsha256/%s/%s",
+		encodeBase62(hashLength),
+		encodeBase62(iterations))
+}
+
+// CalibratePBKDF2 measures the current machine and returns a PBKDF2 strategy
+// whose iteration count makes a single hash take roughly the target duration.
+// It never returns fewer iterations than MinimumStrategy requires.
+//
+// Calibration hashes repeatedly and is therefore slow by design.
+// Run it once (e.g. at startup) and cache the resulting strategy.
+func CalibratePBKDF2(target time.Duration) (strategy string, err error) {
+	if target <= 0 {
+		return "", errors.New("target duration must be greater than zero")
+	}
+
+	hashLength := 64
+	password := rng.GenerateBytes(16)
+	salt := rng.GenerateBytes(32)
+
+	// Start at the security floor, so that the strategy is accepted by WithStrategy
+	iterations := MinimumStrategy.MinPbkdf2Iterations
+	if iterations < 1 {
+		iterations = 1
+	}
+	if hashLength < MinimumStrategy.MinHashLength {
+		hashLength = MinimumStrategy.MinHashLength
+	}
+	for {
+		computeHash, err := createPbkdf2Fn(pbkdf2Strategy(hashLength, iterations))
+		if err != nil {
+			return "", fmt.Errorf("failed to create PBKDF2 hashing function: %w", err)
+		}
+
+		start := time.Now()
+		computeHash(password, salt)
+		elapsed := time.Since(start)
+
+		if elapsed >= target {
+			return pbkdf2Strategy(hashLength, iterations), nil
+		}
+
+		// Scale linearly once the measurement is long enough to be meaningful,
+		// otherwise keep doubling to avoid overshooting on timer noise.
+		next := iterations * 2
+		if elapsed >= target/4 {
+			next = int(float64(iterations) * float64(target) / float64(elapsed))
+			if next <= iterations {
+				next = iterations + 1
+			}
+		}
+		if next > maxPbkdf2Iterations {
+			return "", errors.New("target duration cannot be reached within the maximum number of iterations")
+		}
+		iterations = next
+	}
+}
+
+// CalibrateArgon2id measures the current machine and returns an Argon2id strategy
+// with the given memory in KiB whose number of iterations makes a single hash take
+// roughly the target duration. Unlike PBKDF2 the memory cost is fixed by the caller,
+// because it is bound by the memory available per concurrent login rather than by time.
+//
+// Calibration hashes repeatedly and is therefore slow by design.
+// Run it once (e.g. at startup) and cache the resulting strategy.
+func CalibrateArgon2id(target time.Duration, memKiB int) (strategy string, err error) {
+	if target <= 0 {
+		return "", errors.New("target duration must be greater than zero")
+	}
+
+	hashLength := 32
+	threads := 1
+	password := rng.GenerateBytes(16)
+	salt := rng.GenerateBytes(32)
+
+	iterations := 1
+	for {
+		computeHash, err := createArgon2idFn(Argon2idStrategy(memKiB, iterations, threads, hashLength))
+		if err != nil {
+			return "", fmt.Errorf("failed to create Argon2id hashing function: %w", err)
+		}
+
+		start := time.Now()
+		computeHash(password, salt)
+		elapsed := time.Since(start)
+
+		if elapsed >= target {
+			return Argon2idStrategy(memKiB, iterations, threads, hashLength), nil
+		}
+
+		// Scale linearly once the measurement is long enough to be meaningful,
+		// otherwise keep doubling to avoid overshooting on timer noise.
+		next := iterations * 2
+		if elapsed >= target/4 {
+			next = int(float64(iterations) * float64(target) / float64(elapsed))
+			if next <= iterations {
+				next = iterations + 1
+			}
+		}
+		if next > maxArgon2Iterations {
+			return "", errors.New("target duration cannot be reached within the maximum number of iterations")
+		}
+		iterations = next
+	}
+}
+
+// StrategyCost reports the cost parameters of a built-in hashing strategy.
+// iterations is the number of key stretching iterations and memKiB the amount
+// of memory in KiB required per hash (zero for strategies which aren't memory-hard).
+func StrategyCost(strategy string) (iterations int, memKiB int, err error) {
+	name := strings.SplitN(strategy, "/", 2)[0]
+	switch name {
+	case "pbkdf2":
+		params, err := parsePbkdf2Strategy(strategy)
+		if err != nil {
+			return 0, 0, err
+		}
+		return params.iterations, 0, nil
+	case "argon2id":
+		params, err := parseArgon2Strategy(strategy)
+		if err != nil {
+			return 0, 0, err
+		}
+		return params.iterations, params.memoryKiB, nil
+	default:
+		return 0, 0, fmt.Errorf("cost of strategy is unknown: %s", strategy)
+	}
+}
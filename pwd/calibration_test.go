@@ -0,0 +1,92 @@
+package pwd
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_CalibratePBKDF2_WithTinyTarget_ReturnsUsableStrategy(t *testing.T) {
+	strategy, err := CalibratePBKDF2(time.Millisecond)
+
+	if err != nil {
+		t.Error("CalibratePBKDF2 returned an unexpected error: " + err.Error())
+	}
+
+	if _, err := createPasswordHashingStrategy(strategy); err != nil {
+		t.Error("Calibrated strategy was expected to be usable:", strategy)
+	}
+}
+
+func Test_CalibratePBKDF2_WithZeroTarget_ReturnsError(t *testing.T) {
+	if _, err := CalibratePBKDF2(0); err == nil {
+		t.Error("CalibratePBKDF2 was expected to return an error.")
+	}
+}
+
+func Test_CalibrateArgon2id_WithTinyTargetAndSmallMemory_ReturnsUsableStrategy(t *testing.T) {
+	strategy, err := CalibrateArgon2id(time.Millisecond, 64)
+
+	if err != nil {
+		t.Error("CalibrateArgon2id returned an unexpected error: " + err.Error())
+	}
+
+	if _, err := createPasswordHashingStrategy(strategy); err != nil {
+		t.Error("Calibrated strategy was expected to be usable:", strategy)
+	}
+	if _, memKiB, _ := StrategyCost(strategy); memKiB != 64 {
+		t.Error("Expected memory:", 64, "Actual memory:", memKiB)
+	}
+}
+
+func Test_CalibrateArgon2id_WithInvalidArguments_ReturnsError(t *testing.T) {
+	if _, err := CalibrateArgon2id(0, 64); err == nil {
+		t.Error("CalibrateArgon2id was expected to return an error for a zero target.")
+	}
+	if _, err := CalibrateArgon2id(time.Millisecond, 4); err == nil {
+		t.Error("CalibrateArgon2id was expected to return an error for too little memory.")
+	}
+}
+
+func Test_encodeBase62_MatchesDefaultStrategy(t *testing.T) {
+	areEqual(t, defaultStrategy, pbkdf2Strategy(64, 100000))
+}
+
+func Test_StrategyCost_WithDefaultStrategy_ReturnsIterations(t *testing.T) {
+	iterations, memKiB, err := StrategyCost(defaultStrategy)
+
+	if err != nil {
+		t.Error("StrategyCost returned an unexpected error: " + err.Error())
+	}
+	areEqual(t, 100000, iterations)
+	areEqual(t, 0, memKiB)
+}
+
+func Test_StrategyCost_WithArgon2idStrategy_ReturnsIterationsAndMemory(t *testing.T) {
+	iterations, memKiB, err := StrategyCost(Argon2idStrategy(65536, 3, 4, 32))
+
+	if err != nil {
+		t.Error("StrategyCost returned an unexpected error: " + err.Error())
+	}
+	areEqual(t, 3, iterations)
+	areEqual(t, 65536, memKiB)
+}
+
+func Test_StrategyCost_WithUnknownStrategy_ReturnsError(t *testing.T) {
+	if _, _, err := StrategyCost("unknown/1/2"); err == nil {
+		t.Error("StrategyCost was expected to return an error.")
+	}
+}
+
+func BenchmarkPBKDF2(b *testing.B) {
+	computeHash, err := createPbkdf2Fn(defaultStrategy)
+	if err != nil {
+		b.Fatal(err)
+	}
+	password := []byte("Just4Now!2019")
+	salt := make([]byte, 32)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		computeHash(password, salt)
+	}
+}
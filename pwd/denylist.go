@@ -0,0 +1,48 @@
+package pwd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ------------------
+// Common password denylist
+// ------------------
+
+// CommonPasswordCheckFromReader validates that a password isn't on a denylist of
+// common passwords with one password per line. Leading and trailing whitespace and
+// empty lines are ignored, and passwords are compared case-insensitively.
+//
+// The list is read line by line rather than as a whole, and only the lowercase
+// passwords are kept in a set, so that each check is a single lookup. A list can
+// be bundled with the binary and wired in with a single line:
+//
+//	//go:embed common-passwords.txt
+//	var denylist embed.FS
+//
+//	f, _ := denylist.Open("common-passwords.txt")
+//	check, err := pwd.CommonPasswordCheckFromReader(f)
+func CommonPasswordCheckFromReader(r io.Reader) (validateFunc, error) {
+	if r == nil {
+		panic("r cannot be nil")
+	}
+	denylist := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		password := strings.TrimSpace(scanner.Text())
+		if password != "" {
+			denylist[strings.ToLower(password)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read common passwords: %w", err)
+	}
+	return func(password string) (ok bool, errMsg string) {
+		if _, found := denylist[strings.ToLower(password)]; found {
+			return false, "Password must not be a commonly used password"
+		}
+		return true, ""
+	}, nil
+}
@@ -0,0 +1,39 @@
+package pwd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func Test_CommonPasswordCheckFromReader_WithListedPassword_RejectsPassword(t *testing.T) {
+	list := "123456\npassword\n\n  qwerty  \r\nletmein\n"
+
+	check, err := CommonPasswordCheckFromReader(strings.NewReader(list))
+	areEqual(t, nil, err)
+
+	for _, password := range []string{"password", "Password", "qwerty", "letmein"} {
+		ok, errMsg := check(password)
+		areEqual(t, false, ok)
+		areEqual(t, "Password must not be a commonly used password", errMsg)
+	}
+	for _, password := range []string{"Just4Now!2019", "", "password1"} {
+		if ok, errMsg := check(password); !ok {
+			t.Error("Password was expected to be accepted:", password, errMsg)
+		}
+	}
+}
+
+func Test_CommonPasswordCheckFromReader_WithFailingReader_ReturnsError(t *testing.T) {
+	readErr := errors.New("read failed")
+
+	check, err := CommonPasswordCheckFromReader(iotest.ErrReader(readErr))
+
+	if !errors.Is(err, readErr) {
+		t.Error("Expected:", readErr, "Actual:", err)
+	}
+	if check != nil {
+		t.Error("No check was expected to be returned with an error.")
+	}
+}
@@ -0,0 +1,102 @@
+package pwd
+
+// ------------------
+// Validation events
+// ------------------
+
+// ValidationResult is the outcome of a password validation.
+type ValidationResult int
+
+const (
+	// ValidationSucceeded means that the password matched the hash.
+	ValidationSucceeded ValidationResult = iota
+
+	// ValidationWrongPassword means that the password didn't match the hash.
+	ValidationWrongPassword
+
+	// ValidationInvalidHash means that the stored hash couldn't be parsed
+	// or uses an unsupported strategy.
+	ValidationInvalidHash
+)
+
+// String returns the name of a ValidationResult for logging.
+func (r ValidationResult) String() string {
+	switch r {
+	case ValidationSucceeded:
+		return "succeeded"
+	case ValidationWrongPassword:
+		return "wrong password"
+	case ValidationInvalidHash:
+		return "invalid hash"
+	default:
+		return "unknown"
+	}
+}
+
+// ValidationEvent describes a password validation for auditing.
+// It never contains the password, the salt or the hash.
+type ValidationEvent struct {
+	Result ValidationResult
+
+	// Strategy of the stored hash (e.g. "pbkdf2/hmacsha256/12/G8"),
+	// empty if the hash couldn't be parsed.
+	Strategy string
+
+	// NeedsUpgrade is true if the password matched and the hash should be re-computed.
+	NeedsUpgrade bool
+}
+
+// ValidationHook observes password validations.
+type ValidationHook = func(event ValidationEvent)
+
+// WithValidationHook makes the Validator invoke the hook after each ValidatePassword,
+// ValidateTimed and ValidateAny call, e.g. to log failed logins and hash upgrades without the package depending
+// on a logger. Only these login-facing calls emit events; internal comparisons such as
+// NotReused don't. The hook runs synchronously and should return quickly.
+func WithValidationHook(hook ValidationHook) ValidatorOption {
+	if hook == nil {
+		panic("hook cannot be nil")
+	}
+	return func(v *Validator) {
+		v.onValidation = hook
+	}
+}
+
+// validationEvent derives the non-sensitive metadata of a validation.
+func (v *Validator) validationEvent(passwordHash string, ok bool, needsUpgrade bool) ValidationEvent {
+	pwdh, err := v.parseHash(passwordHash)
+	if err != nil {
+		return ValidationEvent{Result: ValidationInvalidHash}
+	}
+	event := ValidationEvent{Strategy: pwdh.strategy, NeedsUpgrade: needsUpgrade}
+	switch {
+	case ok:
+		event.Result = ValidationSucceeded
+	case v.hasStrategy(pwdh.strategy) && pwdh.hasDeclaredLength():
+		event.Result = ValidationWrongPassword
+	default:
+		event.Result = ValidationInvalidHash
+	}
+	return event
+}
+
+// anyValidationEvent derives the metadata of a ValidateAny call as a single event:
+// the event of the matching hash, otherwise of the first hash with a wrong password,
+// otherwise an invalid hash.
+func (v *Validator) anyValidationEvent(hashes []string, matchedIndex int, needsUpgrade bool) ValidationEvent {
+	if matchedIndex >= 0 {
+		return v.validationEvent(hashes[matchedIndex], true, needsUpgrade)
+	}
+	for _, h := range hashes {
+		if event := v.validationEvent(h, false, false); event.Result == ValidationWrongPassword {
+			return event
+		}
+	}
+	return ValidationEvent{Result: ValidationInvalidHash}
+}
+
+// hasStrategy reports whether the Validator can compute hashes of a strategy.
+func (v *Validator) hasStrategy(strategy string) bool {
+	_, err := v.computeHashFactory(strategy)
+	return err == nil
+}
@@ -0,0 +1,85 @@
+package pwd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ------------------
+// Strategy floor
+// ------------------
+
+// ErrWeakStrategy is returned when a strategy falls below the security floor.
+var ErrWeakStrategy = errors.New("strategy is below the security floor")
+
+// StrategyFloor declares the lowest cost parameters of a hashing strategy.
+// Parameters with a zero value are not enforced.
+type StrategyFloor struct {
+	MinHashLength       int
+	MinPbkdf2Iterations int
+	MinArgon2MemoryKiB  int
+	MinArgon2Iterations int
+}
+
+// MinimumStrategy is the security floor which WithStrategy enforces, so that a
+// dangerously weak strategy (e.g. "pbkdf2/hmacsha256/1/1") cannot reach production
+// by accident. It can be raised for stricter requirements and must be set during
+// initialisation, before any Hasher is created. The default strategy of NewHasher
+// meets the default floor. Tests which need cheap strategies should use
+// WithInsecureStrategy instead of lowering the floor.
+var MinimumStrategy = StrategyFloor{
+	MinHashLength:       16,
+	MinPbkdf2Iterations: 100000,
+	MinArgon2MemoryKiB:  19 * 1024,
+	MinArgon2Iterations: 1,
+}
+
+// check returns ErrWeakStrategy if a strategy falls below the floor.
+// The cost of custom strategies of RegisterStrategy is unknown, therefore only the
+// length of a probe hash is checked for them.
+func (f StrategyFloor) check(strategy string) error {
+	switch strings.SplitN(strategy, "/", 2)[0] {
+	case "pbkdf2":
+		params, err := parsePbkdf2Strategy(strategy)
+		if err != nil {
+			return err
+		}
+		if params.hashLength < f.MinHashLength {
+			return fmt.Errorf("%w: hash length must be at least %d bytes", ErrWeakStrategy, f.MinHashLength)
+		}
+		if params.iterations < f.MinPbkdf2Iterations {
+			return fmt.Errorf("%w: iterations must be at least %d", ErrWeakStrategy, f.MinPbkdf2Iterations)
+		}
+	case "argon2id":
+		params, err := parseArgon2Strategy(strategy)
+		if err != nil {
+			return err
+		}
+		if params.hashLength < f.MinHashLength {
+			return fmt.Errorf("%w: hash length must be at least %d bytes", ErrWeakStrategy, f.MinHashLength)
+		}
+		if params.iterations < f.MinArgon2Iterations {
+			return fmt.Errorf("%w: iterations must be at least %d", ErrWeakStrategy, f.MinArgon2Iterations)
+		}
+		if params.memoryKiB < f.MinArgon2MemoryKiB {
+			return fmt.Errorf("%w: memory must be at least %d KiB", ErrWeakStrategy, f.MinArgon2MemoryKiB)
+		}
+	default:
+		computeHash, err := createPasswordHashingStrategy(strategy)
+		if err != nil {
+			return err
+		}
+		if len(computeHash([]byte("probe"), make([]byte, 32))) < f.MinHashLength {
+			return fmt.Errorf("%w: hash length must be at least %d bytes", ErrWeakStrategy, f.MinHashLength)
+		}
+	}
+	return nil
+}
+
+// WithInsecureStrategy makes the Hasher compute hashes with the given strategy like
+// WithStrategy, but without enforcing the security floor. It exists for tests which
+// need fast hashing and must never be used in production.
+func WithInsecureStrategy(strategy string) HasherOption {
+	return withStrategy(strategy)
+}
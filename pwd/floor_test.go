@@ -0,0 +1,132 @@
+package pwd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_WithStrategy_WithWeakStrategy_Panics(t *testing.T) {
+	for _, strategy := range []string{
+		"pbkdf2/hmacsha256/1/1",
+		pbkdf2Strategy(64, 1000),
+		pbkdf2Strategy(64, 99999),
+		pbkdf2Strategy(8, 100000),
+		Argon2idStrategy(64, 1, 1, 32),
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("WithStrategy was expected to panic for a weak strategy:", strategy)
+				}
+			}()
+			WithStrategy(strategy)
+		}()
+	}
+}
+
+func Test_WithStrategy_WithStrongStrategy_DoesNotPanic(t *testing.T) {
+	for _, strategy := range []string{
+		pbkdf2Strategy(32, 100000),
+		pbkdf2Strategy(64, 600000),
+		Argon2idStrategy(19*1024, 2, 1, 32),
+	} {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					t.Error("WithStrategy was not expected to panic for strategy:", strategy, err)
+				}
+			}()
+			WithStrategy(strategy)
+		}()
+	}
+}
+
+func Test_WithInsecureStrategy_WithWeakStrategy_ComputesHash(t *testing.T) {
+	strategy := "pbkdf2/hmacsha256/1/1"
+
+	if err := MinimumStrategy.check(strategy); !errors.Is(err, ErrWeakStrategy) {
+		t.Error("Expected:", ErrWeakStrategy, "Actual:", err)
+	}
+
+	hash := NewHasher(WithInsecureStrategy(strategy)).ComputeHash("Just4Now!2019")
+	actual, err := HashStrategy(hash)
+	areEqual(t, nil, err)
+	areEqual(t, strategy, actual)
+}
+
+func Test_WithStrategy_WithRegisteredStrategy_ChecksHashLength(t *testing.T) {
+	RegisterStrategy("floor-short", func(string) (hashFunc, error) {
+		return func(password []byte, salt []byte) []byte { return []byte{1, 2, 3, 4} }, nil
+	})
+	RegisterStrategy("floor-long", func(string) (hashFunc, error) {
+		return func(password []byte, salt []byte) []byte { return make([]byte, 32) }, nil
+	})
+
+	if err := MinimumStrategy.check("floor-short/1"); !errors.Is(err, ErrWeakStrategy) {
+		t.Error("Expected:", ErrWeakStrategy, "Actual:", err)
+	}
+	areEqual(t, nil, MinimumStrategy.check("floor-long/1"))
+}
+
+func Test_CalibratePBKDF2_ReturnsStrategyAboveFloor(t *testing.T) {
+	strategy, err := CalibratePBKDF2(time.Millisecond)
+
+	areEqual(t, nil, err)
+	areEqual(t, nil, MinimumStrategy.check(strategy))
+}
+
+func Test_NewHasher_DefaultStrategy_MeetsFloor(t *testing.T) {
+	areEqual(t, nil, MinimumStrategy.check(defaultStrategy))
+
+	hash := NewPHCHasher().ComputeHash("Just4Now!2019")
+	strategy, err := HashStrategy(hash)
+	areEqual(t, nil, err)
+	areEqual(t, nil, MinimumStrategy.check(strategy))
+}
+
+func Test_MinimumStrategy_WhenRaised_AppliesToWithStrategyAndCalibration(t *testing.T) {
+	floor := MinimumStrategy
+	defer func() { MinimumStrategy = floor }()
+	MinimumStrategy.MinPbkdf2Iterations = 200000
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("WithStrategy was expected to panic below the raised floor.")
+			}
+		}()
+		WithStrategy(pbkdf2Strategy(64, 150000))
+	}()
+
+	strategy, err := CalibratePBKDF2(time.Millisecond)
+	areEqual(t, nil, err)
+	iterations, _, err := StrategyCost(strategy)
+	areEqual(t, nil, err)
+	if iterations < 200000 {
+		t.Error("Calibrated iterations were expected to meet the raised floor:", iterations)
+	}
+}
+
+func Test_RegisterStrategy_WithBuiltInName_Panics(t *testing.T) {
+	for _, name := range []string{"pbkdf2", "argon2id"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("RegisterStrategy was expected to panic for built-in strategy:", name)
+				}
+			}()
+			RegisterStrategy(name, func(string) (hashFunc, error) {
+				return func(password []byte, salt []byte) []byte { return make([]byte, 32) }, nil
+			})
+		}()
+	}
+
+	// The built-in strategy is still in place
+	computeHash, err := createPasswordHashingStrategy(pbkdf2Strategy(32, 1))
+	areEqual(t, nil, err)
+	if bytes.Equal(computeHash([]byte("a"), []byte("b")), make([]byte, 32)) {
+		t.Error("Built-in pbkdf2 strategy was expected not to be replaced.")
+	}
+}
@@ -0,0 +1,91 @@
+package pwd
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/dusted-go/security/rng"
+)
+
+// ------------------
+// Password generation
+// ------------------
+
+// Character classes of generated passwords. Special characters are limited
+// to ASCII characters which are easy to type and need no escaping.
+var generatorClasses = []string{
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"abcdefghijklmnopqrstuvwxyz",
+	"0123456789",
+	"!@#$%^&*()_-+=[]{}:;,.?/~",
+}
+
+// Maximum number of candidates which are generated before Generate gives up.
+const maxGenerateAttempts = 100
+
+// randomIndex returns a uniformly distributed random number in [0, n) read from rng.Reader.
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rng.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return int(i.Int64()), nil
+}
+
+// generateCandidate generates a random password which contains a character of
+// every class as far as the length permits.
+func generateCandidate(length int) (string, error) {
+	alphabet := strings.Join(generatorClasses, "")
+	password := make([]byte, length)
+
+	// 1. Pick one character of each class first and fill up with any character
+	for i := range password {
+		chars := alphabet
+		if i < len(generatorClasses) {
+			chars = generatorClasses[i]
+		}
+		j, err := randomIndex(len(chars))
+		if err != nil {
+			return "", err
+		}
+		password[i] = chars[j]
+	}
+
+	// 2. Shuffle the password so that the classes are not in a predictable order
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// Generate creates a random password of the given length from uppercase and lowercase
+// letters, digits and special characters which satisfies the given policy, e.g. to
+// suggest a strong password or to seed test accounts. It uses the CSPRNG of the rng
+// package. Candidates which don't satisfy the policy are discarded, and an error is
+// returned if no candidate satisfied it after a bounded number of attempts.
+func Generate(policy PolicyFunc, length int) (string, error) {
+	if policy == nil {
+		panic("policy cannot be nil")
+	}
+	if length <= 0 {
+		return "", errors.New("length must be greater than zero")
+	}
+
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		password, err := generateCandidate(length)
+		if err != nil {
+			return "", fmt.Errorf("could not generate password: %w", err)
+		}
+		if ok, _ := policy(password); ok {
+			return password, nil
+		}
+	}
+	return "", fmt.Errorf("could not generate a password of length %d which satisfies the policy", length)
+}
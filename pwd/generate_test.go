@@ -0,0 +1,36 @@
+package pwd
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func Test_Generate_WithDefaultPolicy_ReturnsValidPasswordOfLength(t *testing.T) {
+	for _, length := range []int{8, 12, 32} {
+		password, err := Generate(DefaultPolicy, length)
+
+		areEqual(t, nil, err)
+		areEqual(t, length, utf8.RuneCountInString(password))
+		if ok, errMsgs := DefaultPolicy(password); !ok {
+			t.Error("Generated password was expected to pass the default policy:", password, errMsgs)
+		}
+	}
+}
+
+func Test_Generate_ReturnsDifferentPasswords(t *testing.T) {
+	password1, _ := Generate(DefaultPolicy, 16)
+	password2, _ := Generate(DefaultPolicy, 16)
+
+	if password1 == password2 {
+		t.Error("Generated passwords were expected to differ:", password1)
+	}
+}
+
+func Test_Generate_WithUnsatisfiablePolicy_ReturnsError(t *testing.T) {
+	if _, err := Generate(DefaultPolicy, 4); err == nil {
+		t.Error("Generate was expected to fail for a policy which requires a longer password.")
+	}
+	if _, err := Generate(DefaultPolicy, 0); err == nil {
+		t.Error("Generate was expected to fail for a length of zero.")
+	}
+}
@@ -1,17 +1,21 @@
 package pwd
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/dusted-go/security/compare"
 	"github.com/dusted-go/security/rng"
 
-	"github.com/dusted-go/encoding/base62"
-
 	"golang.org/x/crypto/pbkdf2"
 )
 
@@ -28,6 +32,21 @@ type hashFunc = func(password []byte, salt []byte) []byte
 // hashFuncFactory creates a hashFunc from a given strategy.
 type hashFuncFactory = func(strategy string) (hashFunc, error)
 
+// decodeSegment decodes a salt or hash segment. Hex encoded segments are marked
+// by a metadata segment. Otherwise standard base64 is tried first and URL-safe base64
+// second. Both alphabets only differ in two characters and the padding, therefore
+// whenever both decodings succeed they yield the same bytes.
+func decodeSegment(segment string, hexEncoded bool) ([]byte, error) {
+	if hexEncoded {
+		return hex.DecodeString(segment)
+	}
+	b, err := base64.StdEncoding.DecodeString(segment)
+	if err == nil {
+		return b, nil
+	}
+	return base64.RawURLEncoding.DecodeString(segment)
+}
+
 // parseHashFunc parsed a password hash.
 type parseHashFunc = func(passwordHash string) (*passwordHash, error)
 
@@ -38,35 +57,119 @@ type passwordHash struct {
 	strategy   string
 	base64Salt string
 	base64Hash string
+
+	// Header of a hash in the PHC string format, empty for the native format.
+	phcHeader string
+
+	// True if the salt and hash segments are hex instead of base64 encoded.
+	hexEncoded bool
+
+	// Time of hashing from the optional metadata segment, zero if there is none.
+	hashedAt    time.Time
+	encHashedAt string
 }
 
 // Returns the string representation of a passwordHash.
 // Use this value to store in a database.
 func (pwdh *passwordHash) String() string {
-	return fmt.Sprintf(
+	if pwdh.phcHeader != "" {
+		return fmt.Sprintf("%s$%s$%s", pwdh.phcHeader, pwdh.base64Salt, pwdh.base64Hash)
+	}
+	s := fmt.Sprintf(
 		"%s.%s.%s",
 		pwdh.strategy,
 		pwdh.base64Salt,
 		pwdh.base64Hash)
+	if pwdh.hexEncoded {
+		s += "." + hexEncodingSegment
+	}
+	if pwdh.encHashedAt != "" {
+		s += "." + pwdh.encHashedAt
+	}
+	return s
 }
 
+// ------------------
+// Errors
+// ------------------
+
+// ErrInvalidPartCount is returned when a password hash doesn't consist of a strategy, salt and hash.
+var ErrInvalidPartCount = errors.New("password hash must consist of 3 parts: strategy, salt and hash")
+
+// ErrInvalidSalt is returned when the salt of a password hash is not base64 or hex encoded.
+var ErrInvalidSalt = errors.New("salt must be base64 or hex encoded")
+
+// ErrInvalidHash is returned when the hash of a password hash is not base64 or hex encoded.
+var ErrInvalidHash = errors.New("hash must be base64 or hex encoded")
+
 // ------------------
 // Settings
 // ------------------
 
-// Current default hashing strategy.
-var defaultStrategy = "pbkdf2/hmacsha256/12/G8"
+// Current default hashing strategy: PBKDF2 with a 64 byte hash and 100000 iterations,
+// which meets the default MinimumStrategy.
+var defaultStrategy = "pbkdf2/hmacsha256/12/Q0u"
 
 // Map of currently supported hashing strategies.
+// Access must be guarded by strategiesMu.
 var supportedStrategies = map[string]hashFuncFactory{
-	"pbkdf2": createPbkdf2Fn}
+	"pbkdf2":   createPbkdf2Fn,
+	"argon2id": createArgon2idFn}
+
+// Guards reads and writes to supportedStrategies.
+var strategiesMu sync.RWMutex
+
+// RegisterStrategy adds a custom hashing strategy under the given identifier.
+// The identifier is matched against the first segment of a strategy string
+// (e.g. "pbkdf2" in "pbkdf2/hmacsha256/12/G8").
+// The built-in strategies cannot be replaced, because MinimumStrategy relies on their
+// cost parameters. The cost of a custom strategy is unknown, therefore WithStrategy
+// only checks its hash length against MinimumStrategy.
+// It is safe to call RegisterStrategy concurrently with hashing and validation.
+func RegisterStrategy(name string, factory hashFuncFactory) {
+	if name == "" {
+		panic("name cannot be empty")
+	}
+	if name == "pbkdf2" || name == "argon2id" {
+		panic("name cannot be a built-in strategy")
+	}
+	if strings.Contains(name, "/") {
+		panic("name cannot contain a forward slash")
+	}
+	if factory == nil {
+		panic("factory cannot be nil")
+	}
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	supportedStrategies[name] = factory
+}
+
+// SupportedStrategies returns the sorted identifiers of all hashing strategies
+// (e.g. "pbkdf2"), including those added with RegisterStrategy.
+func SupportedStrategies() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	names := make([]string, 0, len(supportedStrategies))
+	for name := range supportedStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
 // ------------------
 // Private helper functions
 // ------------------
 
-// Factory method to create the PBKDF2 key stretching algorithm.
-func createPbkdf2Fn(strategy string) (hashFunc, error) {
+// Parameters of a PBKDF2 strategy.
+type pbkdf2Params struct {
+	hashFuncName string
+	hashLength   int
+	iterations   int
+}
+
+// Parses and validates the parameters of a PBKDF2 strategy.
+func parsePbkdf2Strategy(strategy string) (*pbkdf2Params, error) {
 	errInvalidStrategy := errors.New("invalid strategy, cannot create PBKDF2 hashing function")
 
 	// PBKDF2 has 4 required parameters:
@@ -76,7 +179,7 @@ func createPbkdf2Fn(strategy string) (hashFunc, error) {
 	// 4. The number of iterations to stretch the key
 	expectedArgs := 4
 	args := strings.SplitN(strategy, "/", expectedArgs)
-	if len(args) != expectedArgs {
+	if len(args) != expectedArgs || args[0] != "pbkdf2" {
 		return nil, errInvalidStrategy
 	}
 
@@ -87,9 +190,47 @@ func createPbkdf2Fn(strategy string) (hashFunc, error) {
 		return nil, errInvalidStrategy
 	}
 
+	// A zero length would produce an empty hash which matches any other empty hash
+	hashLength, err := decodeBase62(encHashLength)
+	if err != nil {
+		return nil, fmt.Errorf("%w: hash length: %w", errInvalidStrategy, err)
+	}
+	if hashLength <= 0 {
+		return nil, fmt.Errorf("%w: hash length must be greater than zero", errInvalidStrategy)
+	}
+
+	// Strategies can come from untrusted hash strings and must not exhaust memory or CPU
+	if hashLength > maxPbkdf2HashLength {
+		return nil, fmt.Errorf("%w: hash length must not exceed %d bytes", errInvalidStrategy, maxPbkdf2HashLength)
+	}
+
+	iterations, err := decodeBase62(encIterations)
+	if err != nil {
+		return nil, fmt.Errorf("%w: iterations: %w", errInvalidStrategy, err)
+	}
+	if iterations <= 0 {
+		return nil, fmt.Errorf("%w: iterations must be greater than zero", errInvalidStrategy)
+	}
+	if iterations > maxPbkdf2Iterations {
+		return nil, fmt.Errorf("%w: iterations must not exceed %d", errInvalidStrategy, maxPbkdf2Iterations)
+	}
+
+	return &pbkdf2Params{
+		hashFuncName: hashFuncName,
+		hashLength:   hashLength,
+		iterations:   iterations}, nil
+}
+
+// Factory method to create the PBKDF2 key stretching algorithm.
+func createPbkdf2Fn(strategy string) (hashFunc, error) {
+	params, err := parsePbkdf2Strategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+
 	hashFunc := sha256.New
-	hashLength := base62.DecodeToInt(encHashLength)
-	iterations := base62.DecodeToInt(encIterations)
+	hashLength := params.hashLength
+	iterations := params.iterations
 
 	computeHash := func(password []byte, salt []byte) []byte {
 		return pbkdf2.Key(
@@ -109,61 +250,221 @@ func createPasswordHashingStrategy(strategy string) (hashFunc, error) {
 		return nil, errInvalidStrategy
 	}
 
-	for key, createHash := range supportedStrategies {
-		if strings.HasPrefix(strategy, key) {
-			return createHash(strategy)
-		}
-	}
+	name := strings.SplitN(strategy, "/", 2)[0]
+
+	strategiesMu.RLock()
+	createHash, ok := supportedStrategies[name]
+	strategiesMu.RUnlock()
 
-	return nil, errInvalidStrategy
+	if !ok {
+		return nil, errInvalidStrategy
+	}
+	return createHash(strategy)
 }
 
 func parsePasswordHash(pwdh string) (*passwordHash, error) {
-	errInvalidPwdh := fmt.Errorf("string is not a valid passwordHash: %v", pwdh)
+	invalidPwdh := func(reason error) error {
+		return fmt.Errorf("string is not a valid passwordHash: %v: %w", pwdh, reason)
+	}
 
 	// If the hash doesn't consist of 3 parts (strategy, salt, hash) then it's invalid
 	if pwdh == "" {
-		return nil, errInvalidPwdh
+		return nil, invalidPwdh(ErrInvalidPartCount)
+	}
+
+	// Strategies never start with a "$", which marks the PHC string format
+	if strings.HasPrefix(pwdh, "$") {
+		return parsePHCHash(pwdh)
 	}
 
-	expectedParts := 3
-	actualParams := strings.Split(pwdh, ".")
-	if len(actualParams) != expectedParts {
-		return nil, errInvalidPwdh
+	// Split the optional metadata segment from the end
+	parts := pwdh
+	var hashedAt time.Time
+	encHashedAt := ""
+	if sep := strings.LastIndex(parts, "."); sep >= 0 && strings.HasPrefix(parts[sep+1:], hashedAtPrefix) {
+		var err error
+		encHashedAt = parts[sep+1:]
+		hashedAt, err = decodeHashedAt(encHashedAt)
+		if err != nil {
+			return nil, invalidPwdh(err)
+		}
+		parts = parts[:sep]
+	}
+	hexEncoded := strings.HasSuffix(parts, "."+hexEncodingSegment)
+	parts = strings.TrimSuffix(parts, "."+hexEncodingSegment)
+
+	// Split from the right, because hex and base64 segments never contain a dot
+	// whereas a strategy might (e.g. a version number in its parameters)
+	hashSep := strings.LastIndex(parts, ".")
+	if hashSep < 0 {
+		return nil, invalidPwdh(ErrInvalidPartCount)
+	}
+	saltSep := strings.LastIndex(parts[:hashSep], ".")
+	if saltSep < 0 {
+		return nil, invalidPwdh(ErrInvalidPartCount)
 	}
 
 	// Get the strategy, encoded salt and encoded hash in the correct order
-	strategy, encSalt, encHash := actualParams[0], actualParams[1], actualParams[2]
+	strategy, encSalt, encHash := parts[:saltSep], parts[saltSep+1:hashSep], parts[hashSep+1:]
+	if strategy == "" || encSalt == "" || encHash == "" {
+		return nil, invalidPwdh(ErrInvalidPartCount)
+	}
 
-	// If the salt is not base64 encoded then it's an invalid hash
-	salt, err := base64.StdEncoding.DecodeString(encSalt)
+	// If the salt is not base64 or hex encoded then it's an invalid hash
+	salt, err := decodeSegment(encSalt, hexEncoded)
 	if err != nil {
-		return nil, errInvalidPwdh
+		return nil, invalidPwdh(fmt.Errorf("%w: %w", ErrInvalidSalt, err))
 	}
 
-	// If the hash is not base64 encoded then it's an invalid hash
-	hash, err := base64.StdEncoding.DecodeString(encHash)
+	// If the hash is not base64 or hex encoded then it's an invalid hash
+	hash, err := decodeSegment(encHash, hexEncoded)
 	if err != nil {
-		return nil, errInvalidPwdh
+		return nil, invalidPwdh(fmt.Errorf("%w: %w", ErrInvalidHash, err))
 	}
 
 	// Return decomposed passwordHash
 	return &passwordHash{
-		salt:       salt,
-		hash:       hash,
-		strategy:   strategy,
-		base64Salt: encSalt,
-		base64Hash: encHash}, nil
+		salt:        salt,
+		hash:        hash,
+		strategy:    strategy,
+		base64Salt:  encSalt,
+		base64Hash:  encHash,
+		hexEncoded:  hexEncoded,
+		hashedAt:    hashedAt,
+		encHashedAt: encHashedAt}, nil
+}
+
+// HashStrategy returns the hashing strategy of a stored password hash
+// (e.g. "pbkdf2/hmacsha256/12/G8") without validating any password.
+func HashStrategy(storedHash string) (string, error) {
+	pwdh, err := parsePasswordHash(storedHash)
+	if err != nil {
+		return "", err
+	}
+	return pwdh.strategy, nil
+}
+
+// DecodeHash decomposes a stored password hash into its strategy, salt and hash
+// without validating any password, e.g. so that external tooling can re-compute
+// the hash with a canonical implementation. PHC strings are converted into the
+// equivalent strategy.
+func DecodeHash(storedHash string) (strategy string, salt []byte, hash []byte, err error) {
+	pwdh, err := parsePasswordHash(storedHash)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return pwdh.strategy, pwdh.salt, pwdh.hash, nil
+}
+
+// SameParameters reports whether two stored password hashes use the same strategy
+// and salt, without validating any password. Distinct accounts should never share
+// a salt, therefore a match (e.g. in an imported dataset) is a sign of bad hashing.
+// The encoding of the salt (e.g. standard or URL-safe base64) is irrelevant.
+func SameParameters(hashA, hashB string) (bool, error) {
+	pwdhA, err := parsePasswordHash(hashA)
+	if err != nil {
+		return false, err
+	}
+	pwdhB, err := parsePasswordHash(hashB)
+	if err != nil {
+		return false, err
+	}
+	return pwdhA.strategy == pwdhB.strategy && bytes.Equal(pwdhA.salt, pwdhB.salt), nil
+}
+
+// ValidateHashFormat verifies that a stored password hash can be parsed and
+// uses a supported strategy with valid parameters, without validating any password.
+func ValidateHashFormat(storedHash string) error {
+	pwdh, err := parsePasswordHash(storedHash)
+	if err != nil {
+		return err
+	}
+	if _, err := createPasswordHashingStrategy(pwdh.strategy); err != nil {
+		return fmt.Errorf("unsupported strategy %q: %w", pwdh.strategy, err)
+	}
+	return nil
 }
 
 // ------------------
 // Hash Generator
 // ------------------
 
+// Hashing computes password hashes. Hasher implements it, and code which depends
+// on Hashing instead of *Hasher can substitute a fake in tests.
+type Hashing interface {
+	ComputeHash(password string) string
+}
+
+var _ Hashing = (*Hasher)(nil)
+
 type Hasher struct {
 	generateSalt saltFunc
 	computeHash  hashFunc
 	strategy     string
+	encoding     *base64.Encoding
+	hexEncoding  bool
+	normalize    bool
+	phcHeader    string
+	now          func() time.Time
+	pepper       []byte
+}
+
+// HasherOption configures optional behaviour of a Hasher.
+type HasherOption = func(h *Hasher)
+
+// WithURLSafeEncoding makes the Hasher encode the salt and hash segments
+// with unpadded URL-safe base64 instead of standard base64.
+// The Validator accepts both encodings without any further configuration.
+func WithURLSafeEncoding() HasherOption {
+	return func(h *Hasher) {
+		h.encoding = base64.RawURLEncoding
+		h.hexEncoding = false
+	}
+}
+
+// WithHexEncoding makes the Hasher encode the salt and hash segments with
+// lowercase hex instead of standard base64, e.g. for systems which store hashes
+// in fixed-width columns and can't handle special characters.
+// The encoding is recorded in a metadata segment (e.g. "strategy.salt.hash.e=hex"),
+// therefore the Validator accepts such hashes without any further configuration.
+func WithHexEncoding() HasherOption {
+	return func(h *Hasher) {
+		h.hexEncoding = true
+	}
+}
+
+// WithSaltFunc replaces the salt source of the Hasher, e.g. with an HSM-backed
+// or deterministic generator. The function receives the salt length in bytes.
+func WithSaltFunc(f func(int) []byte) HasherOption {
+	if f == nil {
+		panic("f cannot be nil")
+	}
+	return func(h *Hasher) {
+		h.generateSalt = f
+	}
+}
+
+// WithStrategy makes the Hasher compute hashes with the given strategy
+// (e.g. from Argon2idStrategy or CalibratePBKDF2) instead of the default strategy.
+// It panics if the strategy falls below MinimumStrategy.
+// Custom strategies of RegisterStrategy are only checked for their hash length.
+func WithStrategy(strategy string) HasherOption {
+	if err := MinimumStrategy.check(strategy); err != nil {
+		panic(fmt.Errorf("failed to create a hash function: %w", err))
+	}
+	return withStrategy(strategy)
+}
+
+// withStrategy makes the Hasher compute hashes with the given strategy.
+func withStrategy(strategy string) HasherOption {
+	computeHash, err := createPasswordHashingStrategy(strategy)
+	if err != nil {
+		panic(fmt.Errorf("failed to create a hash function: %w", err))
+	}
+	return func(h *Hasher) {
+		h.computeHash = computeHash
+		h.strategy = strategy
+	}
 }
 
 func newHasher(
@@ -180,48 +481,233 @@ func newHasher(
 	return &Hasher{
 		generateSalt: generateSalt,
 		computeHash:  computeHash,
-		strategy:     strategy}
+		strategy:     strategy,
+		encoding:     base64.StdEncoding}
 }
 
 func (h *Hasher) computePasswordHash(password string) *passwordHash {
+	return h.computePasswordHashWithSalt(password, h.newSalt())
+}
+
+// newSalt generates the salt of a new password hash.
+func (h *Hasher) newSalt() []byte {
 	if h.generateSalt == nil {
 		panic("generateSalt cannot be nil")
 	}
+	return h.generateSalt(32)
+}
+
+// computePasswordHashWithSalt computes the hash of a password with a given salt.
+func (h *Hasher) computePasswordHashWithSalt(password string, salt []byte) *passwordHash {
 	if h.computeHash == nil {
 		panic("computeHash cannot be nil")
 	}
+	if h.encoding == nil {
+		panic("encoding cannot be nil")
+	}
 
-	salt := h.generateSalt(32)
-	hash := h.computeHash([]byte(password), salt)
+	if h.normalize {
+		password = NormalizePassword(password)
+	}
 
-	return &passwordHash{
+	hash := h.computeHash(applyPepper(h.pepper, []byte(password)), salt)
+
+	// The PHC string format mandates its own encoding
+	encode := h.encoding.EncodeToString
+	if h.hexEncoding {
+		encode = hex.EncodeToString
+	}
+	if h.phcHeader != "" {
+		encode = phcEncoding.EncodeToString
+	}
+
+	pwdh := &passwordHash{
 		salt:       salt,
 		hash:       hash,
 		strategy:   h.strategy,
-		base64Salt: base64.StdEncoding.EncodeToString(salt),
-		base64Hash: base64.StdEncoding.EncodeToString(hash)}
+		base64Salt: encode(salt),
+		base64Hash: encode(hash),
+		phcHeader:  h.phcHeader,
+		hexEncoded: h.hexEncoding && h.phcHeader == ""}
+
+	// The PHC string format has no room for metadata
+	if h.now != nil && h.phcHeader == "" {
+		pwdh.hashedAt = h.now().UTC().Truncate(time.Second)
+		pwdh.encHashedAt = encodeHashedAt(pwdh.hashedAt)
+	}
+	return pwdh
 }
 
 func (h *Hasher) ComputeHash(password string) string {
 	return h.computePasswordHash(password).String()
 }
 
+// ComputeHashBatch computes the hashes of many passwords (e.g. for a bulk import)
+// on a pool of concurrency workers. The hashes are returned in the same order as the passwords.
+// The salts are generated serially up front, so the salt function of WithSaltFunc
+// is never called concurrently.
+func (h *Hasher) ComputeHashBatch(passwords []string, concurrency int) []string {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// The salt function of WithSaltFunc need not be safe for concurrent use,
+	// therefore all salts are generated before the work is distributed
+	salts := make([][]byte, len(passwords))
+	for i := range passwords {
+		salts[i] = h.newSalt()
+	}
+
+	hashes := make([]string, len(passwords))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				hashes[i] = h.computePasswordHashWithSalt(passwords[i], salts[i]).String()
+			}
+		}()
+	}
+
+	for i := range passwords {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return hashes
+}
+
 // NewHasher creates a new Hasher instance.
-func NewHasher() *Hasher {
-	return newHasher(
+func NewHasher(opts ...HasherOption) *Hasher {
+	h := newHasher(
 		rng.GenerateBytes,
 		createPasswordHashingStrategy,
 		defaultStrategy)
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // ------------------
 // Hash Validator
 // ------------------
 
+// Validating validates passwords against stored password hashes. Validator implements
+// it, and code which depends on Validating instead of *Validator can substitute a fake in tests.
+type Validating interface {
+	ValidatePassword(password string, passwordHash string) (ok bool, needsUpgrade bool)
+}
+
+var _ Validating = (*Validator)(nil)
+
+// UpgradePolicy decides if a password hash computed with the stored strategy
+// should be re-computed with the default strategy.
+type UpgradePolicy = func(storedStrategy, defaultStrategy string) bool
+
+// StrategyNeedsUpgrade requests an upgrade whenever the stored strategy
+// differs from the default strategy, even if the stored strategy is stronger.
+// The strategies are compared in constant time.
+func StrategyNeedsUpgrade(storedStrategy, defaultStrategy string) bool {
+	return subtle.ConstantTimeCompare([]byte(storedStrategy), []byte(defaultStrategy)) != 1
+}
+
+// WeakerStrategyNeedsUpgrade requests an upgrade when the stored strategy is weaker
+// than the default strategy, so that hashes which are stronger than the default
+// are not downgraded. Strategies of the same algorithm are compared by their parsed
+// cost parameters, whereas strategies of different or unknown algorithms always
+// need an upgrade. This is the default UpgradePolicy.
+func WeakerStrategyNeedsUpgrade(storedStrategy, defaultStrategy string) bool {
+	if !StrategyNeedsUpgrade(storedStrategy, defaultStrategy) {
+		return false
+	}
+
+	name := strings.SplitN(storedStrategy, "/", 2)[0]
+	if name != strings.SplitN(defaultStrategy, "/", 2)[0] {
+		return true
+	}
+
+	switch name {
+	case "pbkdf2":
+		stored, err := parsePbkdf2Strategy(storedStrategy)
+		if err != nil {
+			return true
+		}
+		def, err := parsePbkdf2Strategy(defaultStrategy)
+		if err != nil {
+			return true
+		}
+		return stored.hashFuncName != def.hashFuncName ||
+			stored.iterations < def.iterations ||
+			stored.hashLength < def.hashLength
+	case "argon2id":
+		stored, err := parseArgon2Strategy(storedStrategy)
+		if err != nil {
+			return true
+		}
+		def, err := parseArgon2Strategy(defaultStrategy)
+		if err != nil {
+			return true
+		}
+		return stored.iterations < def.iterations ||
+			stored.memoryKiB < def.memoryKiB ||
+			stored.hashLength < def.hashLength
+	default:
+		return true
+	}
+}
+
+// Validator validates passwords against stored password hashes.
+// A Validator is not modified after its creation and is safe for concurrent use
+// by multiple goroutines, so a single instance should be shared. Any state which
+// is added to it later (e.g. caches) must be synchronised to keep this guarantee.
+// Hooks such as a ValidationHook are invoked concurrently as well.
 type Validator struct {
 	parseHash          parseHashFunc
 	computeHashFactory hashFuncFactory
 	defaultStrategy    string
+	upgradePolicy      UpgradePolicy
+	normalize          bool
+	minValidationTime  time.Duration
+	pepper             []byte
+	previousPepper     []byte
+	onValidation       ValidationHook
+}
+
+// ValidatorOption configures optional behaviour of a Validator.
+type ValidatorOption = func(v *Validator)
+
+// WithUpgradePolicy replaces the policy which decides if a hash needs an upgrade.
+func WithUpgradePolicy(policy UpgradePolicy) ValidatorOption {
+	if policy == nil {
+		panic("policy cannot be nil")
+	}
+	return func(v *Validator) {
+		v.upgradePolicy = policy
+	}
+}
+
+// WithMinValidationTime makes every ValidatePassword and ValidateAny call take
+// at least the given duration by sleeping the remainder. This equalises the
+// timing of successful, failed and unknown-user validations at the application layer.
+func WithMinValidationTime(d time.Duration) ValidatorOption {
+	if d < 0 {
+		panic("d cannot be negative")
+	}
+	return func(v *Validator) {
+		v.minValidationTime = d
+	}
+}
+
+// padValidationTime sleeps until the minimum validation time has passed since start.
+func (v *Validator) padValidationTime(start time.Time) {
+	if remaining := v.minValidationTime - time.Since(start); remaining > 0 {
+		time.Sleep(remaining)
+	}
 }
 
 func newValidator(
@@ -232,13 +718,32 @@ func newValidator(
 	return &Validator{
 		parseHash:          parseHash,
 		computeHashFactory: computeHashFactory,
-		defaultStrategy:    defaultStrategy}
+		defaultStrategy:    defaultStrategy,
+		upgradePolicy:      WeakerStrategyNeedsUpgrade}
+}
+
+// hasDeclaredLength reports whether the length of the hash matches the hash length
+// which its pbkdf2 or argon2id strategy declares. Custom strategies of
+// RegisterStrategy don't declare a length and are always accepted.
+func (pwdh *passwordHash) hasDeclaredLength() bool {
+	switch strings.SplitN(pwdh.strategy, "/", 2)[0] {
+	case "pbkdf2":
+		params, err := parsePbkdf2Strategy(pwdh.strategy)
+		return err == nil && len(pwdh.hash) == params.hashLength
+	case "argon2id":
+		params, err := parseArgon2Strategy(pwdh.strategy)
+		return err == nil && len(pwdh.hash) == params.hashLength
+	}
+	return true
 }
 
 func (v *Validator) validatePassword(p string, pwdh *passwordHash) (ok bool, needsUpgrade bool) {
 	if v.computeHashFactory == nil {
 		panic("computeHashFactory cannot be nil")
 	}
+	if v.upgradePolicy == nil {
+		panic("upgradePolicy cannot be nil")
+	}
 	// Set default return values
 	ok = false
 	needsUpgrade = false
@@ -248,6 +753,15 @@ func (v *Validator) validatePassword(p string, pwdh *passwordHash) (ok bool, nee
 		return
 	}
 
+	if v.normalize {
+		p = NormalizePassword(p)
+	}
+
+	// Reject malformed hashes whose length doesn't match their strategy
+	if !pwdh.hasDeclaredLength() {
+		return
+	}
+
 	// Get the hashing function
 	computeHash, err := v.computeHashFactory(pwdh.strategy)
 	if err != nil {
@@ -255,15 +769,43 @@ func (v *Validator) validatePassword(p string, pwdh *passwordHash) (ok bool, nee
 	}
 
 	// Compute the actual hash
-	computedHash := computeHash([]byte(p), pwdh.salt)
+	computedHash := computeHash(applyPepper(v.pepper, []byte(p)), pwdh.salt)
+	ok = compare.Hashes(pwdh.hash, computedHash)
+
+	// Fall back to the previous pepper during a rotation
+	previousPepperMatched := false
+	if !ok && v.previousPepper != nil {
+		computedHash = computeHash(applyPepper(v.previousPepper, []byte(p)), pwdh.salt)
+		ok = compare.Hashes(pwdh.hash, computedHash)
+		previousPepperMatched = ok
+	}
 
 	// Set return values and finish
-	ok = compare.Hashes(pwdh.hash, computedHash)
-	needsUpgrade = ok && pwdh.strategy != v.defaultStrategy
+	needsUpgrade = ok && (previousPepperMatched || v.upgradePolicy(pwdh.strategy, v.defaultStrategy))
 	return
 }
 
 func (v *Validator) ValidatePassword(password string, passwordHash string) (ok bool, needsUpgrade bool) {
+	ok, needsUpgrade, _ = v.ValidateTimed(password, passwordHash)
+	return ok, needsUpgrade
+}
+
+// ValidateTimed validates a password like ValidatePassword and additionally returns
+// the wall-clock time which the hash computation took, e.g. to export it as a metric
+// and alert on latency drift. The measurement excludes the padding of
+// WithMinValidationTime and doesn't change the timing of the validation itself.
+func (v *Validator) ValidateTimed(password string, passwordHash string) (ok bool, needsUpgrade bool, elapsed time.Duration) {
+	start := time.Now()
+	defer v.padValidationTime(start)
+	ok, needsUpgrade = v.validateHash(password, passwordHash)
+	elapsed = time.Since(start)
+	if v.onValidation != nil {
+		v.onValidation(v.validationEvent(passwordHash, ok, needsUpgrade))
+	}
+	return ok, needsUpgrade, elapsed
+}
+
+func (v *Validator) validateHash(password string, passwordHash string) (ok bool, needsUpgrade bool) {
 	if v.parseHash == nil {
 		panic("parseHash cannot be nil")
 	}
@@ -274,10 +816,93 @@ func (v *Validator) ValidatePassword(password string, passwordHash string) (ok b
 	return v.validatePassword(password, pwdh)
 }
 
+// ValidateAny validates a password against multiple candidate hashes.
+// All hashes are always evaluated so that the time taken doesn't reveal
+// which candidate matched. The index of the first matching hash is returned,
+// or -1 if none of them matched. Like ValidatePassword it is a login-facing
+// entry point: the validation hook receives a single event for the whole call
+// and the duration is padded with WithMinValidationTime.
+func (v *Validator) ValidateAny(password string, hashes ...string) (ok bool, matchedIndex int, needsUpgrade bool) {
+	defer v.padValidationTime(time.Now())
+
+	ok, matchedIndex, needsUpgrade = v.validateAny(password, hashes)
+	if v.onValidation != nil {
+		v.onValidation(v.anyValidationEvent(hashes, matchedIndex, needsUpgrade))
+	}
+	return
+}
+
+// validateAny validates a password against multiple candidate hashes like
+// ValidateAny, but neither invokes the validation hook nor pads the duration,
+// e.g. for internal checks which are not a login attempt.
+func (v *Validator) validateAny(password string, hashes []string) (ok bool, matchedIndex int, needsUpgrade bool) {
+	matchedIndex = -1
+	for i, h := range hashes {
+		hashOk, hashNeedsUpgrade := v.validateHash(password, h)
+		if hashOk && matchedIndex < 0 {
+			ok = true
+			matchedIndex = i
+			needsUpgrade = hashNeedsUpgrade
+		}
+	}
+	return
+}
+
+// NeedsUpgrade reports whether a stored password hash should be re-computed
+// with the validator's default strategy according to its UpgradePolicy.
+// Unlike ValidatePassword it doesn't require the password.
+func (v *Validator) NeedsUpgrade(storedHash string) (bool, error) {
+	if v.parseHash == nil {
+		panic("parseHash cannot be nil")
+	}
+	if v.upgradePolicy == nil {
+		panic("upgradePolicy cannot be nil")
+	}
+	pwdh, err := v.parseHash(storedHash)
+	if err != nil {
+		return false, err
+	}
+	return v.upgradePolicy(pwdh.strategy, v.defaultStrategy), nil
+}
+
 // NewValidator creates a new Validator instance.
-func NewValidator() *Validator {
-	return newValidator(
+func NewValidator(opts ...ValidatorOption) *Validator {
+	v := newValidator(
 		parsePasswordHash,
 		createPasswordHashingStrategy,
 		defaultStrategy)
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// ------------------
+// Convenience functions
+// ------------------
+
+// Default Hasher and Validator of the convenience functions.
+var (
+	defaultHasher    = NewHasher()
+	defaultValidator = NewValidator()
+)
+
+// HashPassword computes the hash of a password with a default Hasher,
+// e.g. to seed an admin account from a script.
+func HashPassword(password string) (string, error) {
+	if password == "" {
+		return "", errors.New("password cannot be empty")
+	}
+	return defaultHasher.ComputeHash(password), nil
+}
+
+// VerifyPassword validates a password against a stored hash with a default Validator.
+// Unlike ValidatePassword it returns an error if the stored hash is malformed
+// or uses an unsupported strategy.
+func VerifyPassword(password, hash string) (ok bool, needsUpgrade bool, err error) {
+	if err := ValidateHashFormat(hash); err != nil {
+		return false, false, err
+	}
+	ok, needsUpgrade = defaultValidator.ValidatePassword(password, hash)
+	return ok, needsUpgrade, nil
 }
@@ -2,9 +2,19 @@ package pwd
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/dusted-go/encoding/base62"
+
+	"golang.org/x/crypto/pbkdf2"
 )
 
 func areEqual(t *testing.T, expected interface{}, actual interface{}) {
@@ -37,6 +47,96 @@ func Test_createPasswordHashingStrategy_WithKnownStrategy_ReturnsError(t *testin
 	}
 }
 
+func Test_createPbkdf2Fn_WithOutOfRangeParameters_ReturnsError(t *testing.T) {
+	invalidStrategies := []string{
+		"pbkdf2/hmacsha256/0/G8",
+		"pbkdf2/hmacsha256/12/0",
+		"pbkdf2/hmacsha256/00/00",
+		"pbkdf2/hmacsha256/zzzzzz/G8",
+		"pbkdf2/hmacsha256/12/zzzzzzzz",
+	}
+
+	for _, strategy := range invalidStrategies {
+		hashFunc, err := createPbkdf2Fn(strategy)
+		if hashFunc != nil || err == nil {
+			t.Error("createPbkdf2Fn was expected to reject strategy:", strategy)
+		}
+	}
+}
+
+func Test_createPbkdf2Fn_WithInvalidBase62Characters_ReturnsError(t *testing.T) {
+	invalidStrategies := []string{
+		"pbkdf2/hmacsha256/12/G-8",
+		"pbkdf2/hmacsha256/12/G8!",
+		"pbkdf2/hmacsha256/12/G8/1",
+		"pbkdf2/hmacsha256/12/",
+		"pbkdf2/hmacsha256/1.2/G8",
+		"pbkdf2/hmacsha256/12/Gä",
+		"pbkdf2/hmacsha256/12/00000000000G8",
+	}
+
+	for _, strategy := range invalidStrategies {
+		hashFunc, err := createPbkdf2Fn(strategy)
+		if hashFunc != nil || err == nil {
+			t.Error("createPbkdf2Fn was expected to reject strategy:", strategy)
+		}
+	}
+}
+
+func Test_parseArgon2Strategy_WithInvalidBase62Characters_ReturnsError(t *testing.T) {
+	if _, err := parseArgon2Strategy("argon2id/G8/1/1/W-"); err == nil {
+		t.Error("parseArgon2Strategy was expected to reject an invalid base62 character.")
+	}
+}
+
+func Test_RegisterStrategy_WithConcurrentLookups_IsRaceFree(t *testing.T) {
+	workers := 8
+	var wg sync.WaitGroup
+	wg.Add(workers * 2)
+
+	for i := 0; i < workers; i++ {
+		name := fmt.Sprintf("custom%d", i)
+		go func() {
+			defer wg.Done()
+			RegisterStrategy(name, func(string) (hashFunc, error) {
+				return func(password []byte, salt []byte) []byte { return password }, nil
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := createPasswordHashingStrategy("pbkdf2/hmacsha256/1/1"); err != nil {
+				t.Error("createPasswordHashingStrategy returned an unexpected error: " + err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < workers; i++ {
+		strategy := fmt.Sprintf("custom%d/whatever", i)
+		if _, err := createPasswordHashingStrategy(strategy); err != nil {
+			t.Error("Registered strategy was expected to be found:", strategy)
+		}
+	}
+}
+
+func Test_SupportedStrategies_WithRegisteredStrategy_ContainsBuiltInAndCustom(t *testing.T) {
+	RegisterStrategy("listed", func(string) (hashFunc, error) {
+		return func(password []byte, salt []byte) []byte { return password }, nil
+	})
+
+	strategies := SupportedStrategies()
+
+	for _, expected := range []string{"pbkdf2", "argon2id", "listed"} {
+		found := false
+		for _, s := range strategies {
+			found = found || s == expected
+		}
+		if !found {
+			t.Error("Expected strategy:", expected, "Actual:", strategies)
+		}
+	}
+}
+
 func Test_parsePasswordHash_ParsesStringCorrectly(t *testing.T) {
 	strategy, salt, hash := "blah", []byte{1, 3, 5}, []byte{9, 5, 0}
 	str := fmt.Sprintf(
@@ -61,6 +161,116 @@ func Test_parsePasswordHash_ParsesStringCorrectly(t *testing.T) {
 	}
 }
 
+func Test_parsePasswordHash_WithDotInStrategy_ParsesStringCorrectly(t *testing.T) {
+	strategy, salt, hash := "custom/v1.5/A", []byte{1, 3, 5}, []byte{9, 5, 0}
+	str := fmt.Sprintf(
+		"%v.%v.%v",
+		strategy,
+		base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(hash))
+
+	passwordHash, err := parsePasswordHash(str)
+
+	if err != nil {
+		t.Error("parsePasswordHash returned an unexpected error: " + err.Error())
+		return
+	}
+	areEqual(t, strategy, passwordHash.strategy)
+
+	if !bytes.Equal(salt, passwordHash.salt) {
+		t.Error("Expected:", salt, "Actual:", passwordHash.salt)
+	}
+
+	if !bytes.Equal(hash, passwordHash.hash) {
+		t.Error("Expected:", hash, "Actual:", passwordHash.hash)
+	}
+	areEqual(t, str, passwordHash.String())
+}
+
+func Test_parsePasswordHash_WithEmptySegment_ReturnsError(t *testing.T) {
+	invalidHashes := []string{
+		".AQMF.CQUA",
+		"blah..CQUA",
+		"blah.AQMF.",
+		"..",
+	}
+
+	for _, h := range invalidHashes {
+		if _, err := parsePasswordHash(h); err == nil {
+			t.Error("parsePasswordHash was expected to return an error for:", h)
+		}
+	}
+}
+
+func Test_parsePasswordHash_WithInvalidSegments_ReturnsDistinctErrors(t *testing.T) {
+	invalidHashes := map[string]error{
+		"blah.AQMF":           ErrInvalidPartCount,
+		"blah.not-base64!.AQ": ErrInvalidSalt,
+		"blah.AQMF.not-b64!":  ErrInvalidHash,
+	}
+
+	for h, expected := range invalidHashes {
+		_, err := parsePasswordHash(h)
+		if !errors.Is(err, expected) {
+			t.Error("Expected:", expected, "Actual:", err)
+		}
+	}
+
+	var corruptInput base64.CorruptInputError
+	_, err := parsePasswordHash("blah.AQMF.not-b64!")
+	if !errors.As(err, &corruptInput) {
+		t.Error("Underlying base64 error was expected to be wrapped. Actual:", err)
+	}
+}
+
+func Test_HashStrategy_WithValidHash_ReturnsStrategy(t *testing.T) {
+	pwdHash := "pbkdf2/hmacsha256/A/9.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.4xR4SWrsQI+InQ==" // nolint: gosec
+	expected := "pbkdf2/hmacsha256/A/9"
+
+	actual, err := HashStrategy(pwdHash)
+
+	if err != nil {
+		t.Error("HashStrategy returned an unexpected error: " + err.Error())
+	}
+	areEqual(t, expected, actual)
+}
+
+func Test_HashStrategy_WithMalformedHash_ReturnsError(t *testing.T) {
+	invalidHashes := []string{
+		"",
+		"pbkdf2/hmacsha256/A/9",
+		"pbkdf2/hmacsha256/A/9.not-base64!.4xR4SWrsQI+InQ==",
+	}
+
+	for _, h := range invalidHashes {
+		if _, err := HashStrategy(h); err == nil {
+			t.Error("HashStrategy was expected to return an error for:", h)
+		}
+	}
+}
+
+func Test_ValidateHashFormat_WithValidHash_ReturnsNil(t *testing.T) {
+	pwdHash := "pbkdf2/hmacsha256/A/9.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.4xR4SWrsQI+InQ==" // nolint: gosec
+
+	if err := ValidateHashFormat(pwdHash); err != nil {
+		t.Error("ValidateHashFormat returned an unexpected error: " + err.Error())
+	}
+}
+
+func Test_ValidateHashFormat_WithInvalidHash_ReturnsError(t *testing.T) {
+	invalidHashes := map[string]string{
+		"non-base64 salt":  "pbkdf2/hmacsha256/A/9.not-base64!.4xR4SWrsQI+InQ==",
+		"unknown strategy": "unknown/1/2.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.4xR4SWrsQI+InQ==",
+		"wrong part count": "pbkdf2/hmacsha256/A/9.4xR4SWrsQI+InQ==",
+	}
+
+	for name, h := range invalidHashes {
+		if err := ValidateHashFormat(h); err == nil {
+			t.Error("ValidateHashFormat was expected to return an error for:", name)
+		}
+	}
+}
+
 func Test_ComputePasswordHash_WithPBKDF2_ReturnsCorrectHash(t *testing.T) {
 	salt := []byte{
 		118, 14, 90, 134, 133, 121, 243,
@@ -123,11 +333,57 @@ func Test_ComputePasswordHash_WithPBKDF2andSmallHashLengthAndLowIterations_Retur
 	areEqual(t, expected, actual)
 }
 
+func Test_ComputeHashBatch_MatchesComputeHash(t *testing.T) {
+	salt := []byte{
+		118, 14, 90, 134, 133, 121, 243, 223,
+		197, 125, 68, 206, 135, 80, 102, 59,
+		160, 137, 69, 105, 121, 201, 143, 199,
+		144, 250, 99, 44, 46, 202, 71, 35}
+	generateSaltMock := func(int) []byte { return salt }
+	strategy := "pbkdf2/hmacsha256/A/9"
+	computeHash, _ := createPbkdf2Fn(strategy)
+	passwords := []string{"Just4Now!2019", "a", "", "Just4Now!2020", "b", "c", "d"}
+
+	hasher := newHasher(
+		generateSaltMock,
+		func(string) (hashFunc, error) { return computeHash, nil },
+		strategy)
+	actual := hasher.ComputeHashBatch(passwords, 3)
+
+	areEqual(t, len(passwords), len(actual))
+	for i, password := range passwords {
+		areEqual(t, hasher.ComputeHash(password), actual[i])
+	}
+}
+
+func Test_ComputeHashBatch_GeneratesSaltsSerially(t *testing.T) {
+	// The counter isn't synchronised, so concurrent calls would be reported by the race detector
+	counter := byte(0)
+	saltFunc := func(length int) []byte {
+		counter++
+		return bytes.Repeat([]byte{counter}, length)
+	}
+	hasher := NewHasher(WithInsecureStrategy("pbkdf2/hmacsha256/G/1"), WithSaltFunc(saltFunc))
+	passwords := []string{"a", "b", "c", "d", "e", "f", "g"}
+
+	actual := hasher.ComputeHashBatch(passwords, 4)
+
+	areEqual(t, byte(len(passwords)), counter)
+	for i, hash := range actual {
+		pwdh, err := parsePasswordHash(hash)
+		areEqual(t, nil, err)
+		if !bytes.Equal(bytes.Repeat([]byte{byte(i + 1)}, 32), pwdh.salt) {
+			t.Error("Salts were expected to be generated in order. Actual:", pwdh.salt)
+		}
+	}
+}
+
 func Test_ValidatePassword_WithCorrectPassword_ReturnsTrue(t *testing.T) {
 	password := "Just4Now!2019"
 	pwdHash := "pbkdf2/hmacsha256/12/G8.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.jLyCcDQoSCRAZGQ6epILRXydRYeg6kT+6GsGTuJQe9+iqkxl9cnLrMPtBig4ZuwmEhjP/uye0s0YIw6sS/xcJg==" // nolint
 	expectedResult := true
-	expectedUpgrade := false
+	// The hash uses 1000 iterations, which is weaker than the default strategy
+	expectedUpgrade := true
 
 	validator := NewValidator()
 	actual, requiresUpgrade := validator.ValidatePassword(password, pwdHash)
@@ -136,6 +392,20 @@ func Test_ValidatePassword_WithCorrectPassword_ReturnsTrue(t *testing.T) {
 	areEqual(t, expectedUpgrade, requiresUpgrade)
 }
 
+func Test_ValidatePassword_WithTamperedHashLength_ReturnsFalseAndInvalidHash(t *testing.T) {
+	password := "Just4Now!2019"
+	// The strategy declares a hash length of 63 bytes, but the hash is 64 bytes long
+	pwdHash := "pbkdf2/hmacsha256/11/G8.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.jLyCcDQoSCRAZGQ6epILRXydRYeg6kT+6GsGTuJQe9+iqkxl9cnLrMPtBig4ZuwmEhjP/uye0s0YIw6sS/xcJg==" // nolint
+
+	var event ValidationEvent
+	validator := NewValidator(WithValidationHook(func(e ValidationEvent) { event = e }))
+	actual, requiresUpgrade := validator.ValidatePassword(password, pwdHash)
+
+	areEqual(t, false, actual)
+	areEqual(t, false, requiresUpgrade)
+	areEqual(t, ValidationInvalidHash, event.Result)
+}
+
 func Test_ValidatePassword_WithWrongPassword_ReturnsFalse(t *testing.T) {
 	password := "wrong-PassWord"
 	pwdHash := "pbkdf2/hmacsha256/1S/RS.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.jLyCcDQoSCRAZGQ6epILRXydRYeg6kT+6GsGTuJQe9+iqkxl9cnLrMPtBig4ZuwmEhjP/uye0s0YIw6sS/xcJg==" // nolint: gosec, lll
@@ -161,3 +431,571 @@ func Test_ValidatePassword_WithCorrectPasswordAndOutdatedHash_ReturnsTrueAndTrue
 	areEqual(t, expectedResult, actual)
 	areEqual(t, expectedUpgrade, requiresUpgrade)
 }
+
+func Test_NeedsUpgrade_WithCurrentStrategy_ReturnsFalse(t *testing.T) {
+	pwdHash := NewHasher().ComputeHash("Just4Now!2019")
+
+	validator := NewValidator()
+	needsUpgrade, err := validator.NeedsUpgrade(pwdHash)
+
+	if err != nil {
+		t.Error("NeedsUpgrade returned an unexpected error: " + err.Error())
+	}
+	areEqual(t, false, needsUpgrade)
+}
+
+func Test_NeedsUpgrade_WithOutdatedStrategy_ReturnsTrue(t *testing.T) {
+	pwdHash := "pbkdf2/hmacsha256/A/9.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.4xR4SWrsQI+InQ==" // nolint: gosec
+
+	validator := NewValidator()
+	needsUpgrade, err := validator.NeedsUpgrade(pwdHash)
+
+	if err != nil {
+		t.Error("NeedsUpgrade returned an unexpected error: " + err.Error())
+	}
+	areEqual(t, true, needsUpgrade)
+}
+
+func Test_NeedsUpgrade_WithMalformedHash_ReturnsError(t *testing.T) {
+	validator := NewValidator()
+	_, err := validator.NeedsUpgrade("not-a-hash")
+
+	if err == nil {
+		t.Error("NeedsUpgrade was expected to return an error.")
+	}
+}
+
+func Test_ComputeHash_WithSaltFunc_UsesCustomSalt(t *testing.T) {
+	var requestedLength int
+	saltFunc := func(length int) []byte {
+		requestedLength = length
+		return bytes.Repeat([]byte{7}, length)
+	}
+	hasher := NewHasher(WithSaltFunc(saltFunc))
+
+	pwdh, err := parsePasswordHash(hasher.ComputeHash("Just4Now!2019"))
+
+	areEqual(t, nil, err)
+	areEqual(t, 32, requestedLength)
+	if !bytes.Equal(bytes.Repeat([]byte{7}, 32), pwdh.salt) {
+		t.Error("Expected:", bytes.Repeat([]byte{7}, 32), "Actual:", pwdh.salt)
+	}
+}
+
+func Test_ValidatePassword_WithHexHash_ReturnsTrue(t *testing.T) {
+	password := "Just4Now!2019"
+
+	hasher := NewHasher(WithHexEncoding())
+	pwdHash := hasher.ComputeHash(password)
+
+	if !strings.HasSuffix(pwdHash, ".e=hex") {
+		t.Error("Hash was expected to record the hex encoding:", pwdHash)
+	}
+	parts := strings.Split(pwdHash, ".")
+	encSalt, encHash := parts[len(parts)-3], parts[len(parts)-2]
+	for _, segment := range []string{encSalt, encHash} {
+		if _, err := hex.DecodeString(segment); err != nil || strings.ToLower(segment) != segment {
+			t.Error("Segment was expected to be lowercase hex encoded. Actual:", segment)
+		}
+	}
+	areEqual(t, 64, len(encSalt))
+
+	validator := NewValidator()
+	ok, needsUpgrade := validator.ValidatePassword(password, pwdHash)
+	areEqual(t, true, ok)
+	areEqual(t, false, needsUpgrade)
+
+	ok, _ = validator.ValidatePassword("wrong-PassWord", pwdHash)
+	areEqual(t, false, ok)
+
+	// The encoding marker precedes the time of hashing
+	timestamped := NewHasher(WithHexEncoding(), WithHashTimestamp()).ComputeHash(password)
+	ok, _ = validator.ValidatePassword(password, timestamped)
+	areEqual(t, true, ok)
+	if _, hasTimestamp := HashedAt(timestamped); !hasTimestamp {
+		t.Error("Hash was expected to carry a timestamp:", timestamped)
+	}
+}
+
+func Test_parsePasswordHash_WithBase64SegmentsOfHexDigits_DecodesBase64(t *testing.T) {
+	// Both segments are valid base64 which only consists of lowercase hex digits
+	pwdh, err := parsePasswordHash("pbkdf2/hmacsha256/A/9.abcd.0123456789abcdef")
+
+	areEqual(t, nil, err)
+	expectedSalt, _ := base64.StdEncoding.DecodeString("abcd")
+	expectedHash, _ := base64.StdEncoding.DecodeString("0123456789abcdef")
+	if !bytes.Equal(expectedSalt, pwdh.salt) {
+		t.Error("Expected:", expectedSalt, "Actual:", pwdh.salt)
+	}
+	if !bytes.Equal(expectedHash, pwdh.hash) {
+		t.Error("Expected:", expectedHash, "Actual:", pwdh.hash)
+	}
+}
+
+func Test_ValidatePassword_WithURLSafeHash_ReturnsTrue(t *testing.T) {
+	password := "Just4Now!2019"
+
+	hasher := NewHasher(WithURLSafeEncoding())
+	pwdHash := hasher.ComputeHash(password)
+
+	segments := pwdHash[len(defaultStrategy):]
+	if strings.ContainsAny(segments, "+/=") {
+		t.Error("URL-safe hash segments were expected to not contain '+', '/' or '=':", segments)
+	}
+
+	validator := NewValidator()
+	ok, needsUpgrade := validator.ValidatePassword(password, pwdHash)
+
+	areEqual(t, true, ok)
+	areEqual(t, false, needsUpgrade)
+}
+
+func Test_ValidatePassword_WithCustomUpgradePolicy_UsesPolicy(t *testing.T) {
+	password := "Just4Now!2019"
+	pwdHash := "pbkdf2/hmacsha256/A/9.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.4xR4SWrsQI+InQ==" // nolint: gosec
+
+	// Only upgrade PBKDF2 hashes with fewer than 5 iterations
+	minIterations := 5
+	policy := func(storedStrategy, defaultStrategy string) bool {
+		args := strings.Split(storedStrategy, "/")
+		return base62.DecodeToInt(args[len(args)-1]) < minIterations
+	}
+
+	validator := NewValidator(WithUpgradePolicy(policy))
+	ok, needsUpgrade := validator.ValidatePassword(password, pwdHash)
+
+	areEqual(t, true, ok)
+	areEqual(t, false, needsUpgrade)
+
+	minIterations = 10
+	needsUpgrade, err := validator.NeedsUpgrade(pwdHash)
+
+	if err != nil {
+		t.Error("NeedsUpgrade returned an unexpected error: " + err.Error())
+	}
+	areEqual(t, true, needsUpgrade)
+}
+
+func Test_ValidateAny_WithSecondHashMatching_ReturnsSecondIndex(t *testing.T) {
+	password := "Just4Now!2019"
+	hashes := []string{
+		"pbkdf2/hmacsha256/A/9.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.AAAAAAAAAAAAAA==",
+		"pbkdf2/hmacsha256/A/9.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.4xR4SWrsQI+InQ==", // nolint: gosec
+	}
+
+	validator := NewValidator()
+	ok, matchedIndex, needsUpgrade := validator.ValidateAny(password, hashes...)
+
+	areEqual(t, true, ok)
+	areEqual(t, 1, matchedIndex)
+	areEqual(t, true, needsUpgrade)
+}
+
+func Test_ValidateAny_WithNoMatchingHash_ReturnsMinusOne(t *testing.T) {
+	password := "wrong-PassWord"
+	hashes := []string{
+		"not-a-hash",
+		"pbkdf2/hmacsha256/A/9.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.4xR4SWrsQI+InQ==", // nolint: gosec
+	}
+
+	validator := NewValidator()
+	ok, matchedIndex, needsUpgrade := validator.ValidateAny(password, hashes...)
+
+	areEqual(t, false, ok)
+	areEqual(t, -1, matchedIndex)
+	areEqual(t, false, needsUpgrade)
+}
+
+func Test_ValidateTimed_WithValidHash_ReturnsPositiveElapsed(t *testing.T) {
+	password := "Just4Now!2019"
+	hash := NewHasher().ComputeHash(password)
+
+	ok, needsUpgrade, elapsed := NewValidator().ValidateTimed(password, hash)
+
+	areEqual(t, true, ok)
+	areEqual(t, false, needsUpgrade)
+	if elapsed <= 0 {
+		t.Error("Elapsed time was expected to be positive. Actual:", elapsed)
+	}
+}
+
+func Test_ValidateTimed_WithMinValidationTime_ExcludesPadding(t *testing.T) {
+	minimum := 200 * time.Millisecond
+	password := "Just4Now!2019"
+	// A cheap strategy keeps the hash computation well below the minimum
+	hash := NewHasher(WithInsecureStrategy(pbkdf2Strategy(64, 1000))).ComputeHash(password)
+	validator := NewValidator(WithMinValidationTime(minimum))
+
+	start := time.Now()
+	ok, _, elapsed := validator.ValidateTimed(password, hash)
+	total := time.Since(start)
+
+	areEqual(t, true, ok)
+	if total < minimum {
+		t.Error("Validation was expected to take at least", minimum, "Actual:", total)
+	}
+	if elapsed <= 0 || elapsed >= total {
+		t.Error("Elapsed time was expected to exclude the padding. Actual:", elapsed, "Total:", total)
+	}
+}
+
+func Test_ValidatePassword_WithMinValidationTime_TakesAtLeastMinimum(t *testing.T) {
+	minimum := 50 * time.Millisecond
+	// A cheap strategy keeps the hash computation well below the minimum
+	hash := NewHasher(WithInsecureStrategy(pbkdf2Strategy(64, 1000))).ComputeHash("Just4Now!2019")
+	validator := NewValidator(WithMinValidationTime(minimum))
+
+	for _, storedHash := range []string{hash, "not-a-hash", ""} {
+		start := time.Now()
+		validator.ValidatePassword("Just4Now!2019", storedHash)
+		if elapsed := time.Since(start); elapsed < minimum {
+			t.Error("Validation was expected to take at least", minimum, "Actual:", elapsed)
+		}
+	}
+
+	start := time.Now()
+	validator.ValidateAny("Just4Now!2019", hash, "not-a-hash")
+	if elapsed := time.Since(start); elapsed < minimum || elapsed >= 2*minimum {
+		t.Error("ValidateAny was expected to take the minimum validation time once. Actual:", elapsed)
+	}
+}
+
+func Test_ValidatePassword_WithNormalization_AcceptsDecomposedPassword(t *testing.T) {
+	composed := "Caf\u00e9!2019"
+	decomposed := "Cafe\u0301!2019"
+
+	hasher := NewHasher(WithNormalizedHashing())
+	pwdHash := hasher.ComputeHash(composed)
+
+	ok, _ := NewValidator(WithNormalizedValidation()).ValidatePassword(decomposed, pwdHash)
+	areEqual(t, true, ok)
+
+	ok, _ = NewValidator().ValidatePassword(decomposed, pwdHash)
+	areEqual(t, false, ok)
+}
+
+func Test_StrategyNeedsUpgrade(t *testing.T) {
+	areEqual(t, false, StrategyNeedsUpgrade(defaultStrategy, defaultStrategy))
+	areEqual(t, true, StrategyNeedsUpgrade("pbkdf2/hmacsha256/A/9", defaultStrategy))
+	areEqual(t, true, StrategyNeedsUpgrade("", defaultStrategy))
+}
+
+func Test_WeakerStrategyNeedsUpgrade(t *testing.T) {
+	areEqual(t, false, WeakerStrategyNeedsUpgrade(defaultStrategy, defaultStrategy))
+	areEqual(t, true, WeakerStrategyNeedsUpgrade(pbkdf2Strategy(64, 99999), defaultStrategy))
+	areEqual(t, false, WeakerStrategyNeedsUpgrade(pbkdf2Strategy(64, 100001), defaultStrategy))
+	areEqual(t, true, WeakerStrategyNeedsUpgrade(pbkdf2Strategy(32, 100001), defaultStrategy))
+	areEqual(t, true, WeakerStrategyNeedsUpgrade(Argon2idStrategy(65536, 3, 4, 32), defaultStrategy))
+	areEqual(t, true, WeakerStrategyNeedsUpgrade(Argon2idStrategy(65536, 3, 4, 32), Argon2idStrategy(65536, 4, 4, 32)))
+	areEqual(t, false, WeakerStrategyNeedsUpgrade(Argon2idStrategy(131072, 4, 1, 32), Argon2idStrategy(65536, 4, 4, 32)))
+	areEqual(t, true, WeakerStrategyNeedsUpgrade("", defaultStrategy))
+	areEqual(t, true, WeakerStrategyNeedsUpgrade("pbkdf2/hmacsha256/0/0", defaultStrategy))
+}
+
+func Test_ValidatePassword_WithWeakerEqualAndStrongerHashes_UpgradesOnlyWeaker(t *testing.T) {
+	password := "Just4Now!2019"
+	validator := NewValidator()
+
+	cases := []struct {
+		name         string
+		strategy     string
+		needsUpgrade bool
+	}{
+		{"weaker", pbkdf2Strategy(64, 99999), true},
+		{"equal", defaultStrategy, false},
+		{"stronger", pbkdf2Strategy(64, 100001), false},
+	}
+
+	for _, c := range cases {
+		hash := NewHasher(WithInsecureStrategy(c.strategy)).ComputeHash(password)
+		ok, needsUpgrade := validator.ValidatePassword(password, hash)
+		if !ok || needsUpgrade != c.needsUpgrade {
+			t.Error("Unexpected result for a", c.name, "hash. ok:", ok, "needsUpgrade:", needsUpgrade)
+		}
+	}
+}
+
+func FuzzParsePasswordHash(f *testing.F) {
+	f.Add("pbkdf2/hmacsha256/A/9.dg5ahoV589/FfUTOh1BmO6CJRWl5yY/HkPpjLC7KRyM=.4xR4SWrsQI+InQ==")
+	f.Add("pbkdf2/hmacsha256/A/9.dg5ahoV589_FfUTOh1BmO6CJRWl5yY_HkPpjLC7KRyM.4xR4SWrsQI-InQ")
+	f.Add("custom/v1.5/A.AQMF.CQUA")
+	f.Add("custom/v1.5/A.AQMF.CQUA.t=1m3Kx2")
+	f.Add("$pbkdf2-sha256$i=10000,l=32$cmVmZXJlbmNlLXNhbHQtMTY$JwafcAQgDJAx34tOc7f2AYXy5l86NpOrO58rUxrNFic")
+	f.Add("..")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		pwdh, err := parsePasswordHash(s)
+		if (pwdh == nil) == (err == nil) {
+			t.Error("parsePasswordHash was expected to return either a value or an error for:", s)
+		}
+		if pwdh != nil && pwdh.String() != s {
+			t.Error("Parsed password hash was expected to round-trip. Expected:", s, "Actual:", pwdh.String())
+		}
+
+		// Validating a password against an untrusted hash must not panic either
+		_ = ValidateHashFormat(s)
+	})
+}
+
+func Test_HashPasswordAndVerifyPassword_RoundTrip(t *testing.T) {
+	password := "Just4Now!2019"
+
+	hash, err := HashPassword(password)
+	areEqual(t, nil, err)
+
+	ok, needsUpgrade, err := VerifyPassword(password, hash)
+	areEqual(t, nil, err)
+	areEqual(t, true, ok)
+	areEqual(t, false, needsUpgrade)
+
+	ok, _, err = VerifyPassword("wrong-PassWord", hash)
+	areEqual(t, nil, err)
+	areEqual(t, false, ok)
+}
+
+func Test_HashPassword_WithEmptyPassword_ReturnsError(t *testing.T) {
+	if _, err := HashPassword(""); err == nil {
+		t.Error("HashPassword was expected to return an error for an empty password.")
+	}
+}
+
+func Test_VerifyPassword_WithInvalidHash_ReturnsError(t *testing.T) {
+	for _, hash := range []string{"", "not-a-hash", "unknown/1.AQMF.CQUA"} {
+		if _, _, err := VerifyPassword("Just4Now!2019", hash); err == nil {
+			t.Error("VerifyPassword was expected to return an error for hash:", hash)
+		}
+	}
+}
+
+func Test_VerifyPassword_WithOutdatedHash_NeedsUpgrade(t *testing.T) {
+	hash := NewHasher(WithInsecureStrategy(pbkdf2Strategy(64, 999))).ComputeHash("Just4Now!2019")
+
+	ok, needsUpgrade, err := VerifyPassword("Just4Now!2019", hash)
+
+	areEqual(t, nil, err)
+	areEqual(t, true, ok)
+	areEqual(t, true, needsUpgrade)
+}
+
+func Test_NewValidatorWithPeppers_WithPreviousPepper_ValidatesAndNeedsUpgrade(t *testing.T) {
+	oldPepper := []byte("old-pepper")
+	newPepper := []byte("new-pepper")
+	oldHash := NewHasher(WithPepper(oldPepper)).ComputeHash("Just4Now!2019")
+	newHash := NewHasher(WithPepper(newPepper)).ComputeHash("Just4Now!2019")
+
+	validator := NewValidatorWithPeppers(newPepper, oldPepper)
+
+	ok, needsUpgrade := validator.ValidatePassword("Just4Now!2019", oldHash)
+	areEqual(t, true, ok)
+	areEqual(t, true, needsUpgrade)
+
+	ok, needsUpgrade = validator.ValidatePassword("Just4Now!2019", newHash)
+	areEqual(t, true, ok)
+	areEqual(t, false, needsUpgrade)
+
+	ok, _ = validator.ValidatePassword("wrong-PassWord", oldHash)
+	areEqual(t, false, ok)
+}
+
+func Test_Zeroize_OverwritesPeppers(t *testing.T) {
+	hasherPepper := []byte("hasher-pepper")
+	currentPepper := []byte("current-pepper")
+	previousPepper := []byte("previous-pepper")
+
+	NewHasher(WithPepper(hasherPepper)).Zeroize()
+	NewValidatorWithPeppers(currentPepper, previousPepper).Zeroize()
+	NewValidator().Zeroize()
+
+	for _, pepper := range [][]byte{hasherPepper, currentPepper, previousPepper} {
+		if !bytes.Equal(pepper, make([]byte, len(pepper))) {
+			t.Error("Pepper was expected to be zeroed:", pepper)
+		}
+	}
+}
+
+func Test_NewValidatorWithPeppers_WithoutPreviousPepper_RejectsOldHashes(t *testing.T) {
+	oldHash := NewHasher(WithPepper([]byte("old-pepper"))).ComputeHash("Just4Now!2019")
+
+	ok, _ := NewValidatorWithPeppers([]byte("new-pepper"), nil).ValidatePassword("Just4Now!2019", oldHash)
+	areEqual(t, false, ok)
+
+	ok, _ = NewValidator().ValidatePassword("Just4Now!2019", oldHash)
+	areEqual(t, false, ok)
+}
+
+func Test_WithValidationHook_ReportsSuccessAndFailure(t *testing.T) {
+	var events []ValidationEvent
+	validator := NewValidator(WithValidationHook(func(event ValidationEvent) {
+		events = append(events, event)
+	}))
+	outdatedHash := NewHasher(WithInsecureStrategy(pbkdf2Strategy(64, 999))).ComputeHash("Just4Now!2019")
+	hash := NewHasher().ComputeHash("Just4Now!2019")
+
+	validator.ValidatePassword("Just4Now!2019", hash)
+	validator.ValidatePassword("wrong-PassWord", hash)
+	validator.ValidatePassword("Just4Now!2019", outdatedHash)
+	validator.ValidatePassword("Just4Now!2019", "not-a-hash")
+	validator.ValidatePassword("Just4Now!2019", "unknown/1.AQMF.CQUA")
+
+	expected := []ValidationEvent{
+		{Result: ValidationSucceeded, Strategy: defaultStrategy},
+		{Result: ValidationWrongPassword, Strategy: defaultStrategy},
+		{Result: ValidationSucceeded, Strategy: pbkdf2Strategy(64, 999), NeedsUpgrade: true},
+		{Result: ValidationInvalidHash},
+		{Result: ValidationInvalidHash, Strategy: "unknown/1"},
+	}
+	areEqual(t, len(expected), len(events))
+	for i := range expected {
+		if i < len(events) && events[i] != expected[i] {
+			t.Error("Expected:", expected[i], "Actual:", events[i])
+		}
+	}
+}
+
+func Test_WithValidationHook_ReportsOneEventPerValidateAny(t *testing.T) {
+	var events []ValidationEvent
+	validator := NewValidator(WithValidationHook(func(event ValidationEvent) {
+		events = append(events, event)
+	}))
+	outdatedHash := NewHasher(WithInsecureStrategy(pbkdf2Strategy(64, 999))).ComputeHash("Just4Now!2019")
+	hash := NewHasher().ComputeHash("Just4Now!2019")
+
+	validator.ValidateAny("Just4Now!2019", "not-a-hash", hash, outdatedHash)
+	validator.ValidateAny("Just4Now!2019", "not-a-hash", outdatedHash)
+	validator.ValidateAny("wrong-PassWord", "not-a-hash", hash)
+	validator.ValidateAny("Just4Now!2019", "not-a-hash")
+	validator.ValidateAny("Just4Now!2019")
+
+	expected := []ValidationEvent{
+		{Result: ValidationSucceeded, Strategy: defaultStrategy},
+		{Result: ValidationSucceeded, Strategy: pbkdf2Strategy(64, 999), NeedsUpgrade: true},
+		{Result: ValidationWrongPassword, Strategy: defaultStrategy},
+		{Result: ValidationInvalidHash},
+		{Result: ValidationInvalidHash},
+	}
+	areEqual(t, len(expected), len(events))
+	for i := range expected {
+		if i < len(events) && events[i] != expected[i] {
+			t.Error("Expected:", expected[i], "Actual:", events[i])
+		}
+	}
+}
+
+func Test_DecodeHash_WithKnownHash_AllowsRecomputingHash(t *testing.T) {
+	// Generated with Python's hashlib.pbkdf2_hmac
+	known := "$pbkdf2-sha256$i=10000,l=32$cmVmZXJlbmNlLXNhbHQtMTY$JwafcAQgDJAx34tOc7f2AYXy5l86NpOrO58rUxrNFic"
+
+	strategy, salt, hash, err := DecodeHash(known)
+
+	areEqual(t, nil, err)
+	areEqual(t, pbkdf2Strategy(32, 10000), strategy)
+	areEqual(t, "reference-salt-16", string(salt))
+	recomputed := pbkdf2.Key([]byte("Correct Horse 1!"), salt, 10000, 32, sha256.New)
+	if !bytes.Equal(hash, recomputed) {
+		t.Error("Expected:", hash, "Actual:", recomputed)
+	}
+
+	// The components re-assemble into an equivalent hash in the native format
+	native := strategy + "." + base64.StdEncoding.EncodeToString(salt) + "." + base64.StdEncoding.EncodeToString(hash)
+	ok, _ := NewValidator().ValidatePassword("Correct Horse 1!", native)
+	areEqual(t, true, ok)
+}
+
+func Test_DecodeHash_WithInvalidHash_ReturnsError(t *testing.T) {
+	_, _, _, err := DecodeHash("not-a-hash")
+
+	if !errors.Is(err, ErrInvalidPartCount) {
+		t.Error("Expected:", ErrInvalidPartCount, "Actual:", err)
+	}
+}
+
+// Run with -race to detect unsynchronised state in the Validator.
+func Test_Validator_WithConcurrentValidations_ReturnsConsistentResults(t *testing.T) {
+	hasher := NewHasher(WithInsecureStrategy(pbkdf2Strategy(32, 1000)))
+	hashes := []string{
+		hasher.ComputeHash("Just4Now!2019"),
+		NewPHCHasher(WithInsecureStrategy(pbkdf2Strategy(32, 1000))).ComputeHash("Just4Now!2019"),
+		NewHasher(WithInsecureStrategy(Argon2idStrategy(64, 1, 1, 32))).ComputeHash("Just4Now!2019"),
+	}
+	validator := NewValidator()
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 64)
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hash := hashes[i%len(hashes)]
+			if ok, _ := validator.ValidatePassword("Just4Now!2019", hash); !ok {
+				errs <- "correct password was rejected: " + hash
+			}
+			if ok, _ := validator.ValidatePassword("wrong-PassWord", hash); ok {
+				errs <- "wrong password was accepted: " + hash
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func Test_SameParameters_WithSharedSalt_ReturnsTrue(t *testing.T) {
+	salt := func(length int) []byte { return bytes.Repeat([]byte{7}, length) }
+	hasher := NewHasher(WithSaltFunc(salt))
+	hashA := hasher.ComputeHash("Just4Now!2019")
+	hashB := NewHasher(WithSaltFunc(salt), WithURLSafeEncoding()).ComputeHash("another-PassWord1")
+
+	same, err := SameParameters(hashA, hashB)
+
+	areEqual(t, nil, err)
+	areEqual(t, true, same)
+}
+
+func Test_SameParameters_WithDifferentSaltOrStrategy_ReturnsFalse(t *testing.T) {
+	salt := func(length int) []byte { return bytes.Repeat([]byte{7}, length) }
+	hashA := NewHasher(WithSaltFunc(salt)).ComputeHash("Just4Now!2019")
+	hashB := NewHasher().ComputeHash("Just4Now!2019")
+	hashC := NewHasher(WithSaltFunc(salt), WithInsecureStrategy(pbkdf2Strategy(32, 2000))).ComputeHash("Just4Now!2019")
+
+	same, err := SameParameters(hashA, hashB)
+	areEqual(t, nil, err)
+	areEqual(t, false, same)
+
+	same, err = SameParameters(hashA, hashC)
+	areEqual(t, nil, err)
+	areEqual(t, false, same)
+
+	if _, err := SameParameters(hashA, "not-a-hash"); err == nil {
+		t.Error("SameParameters was expected to return an error for an invalid hash.")
+	}
+}
+
+// Fakes which consumers can inject instead of a Hasher and Validator.
+type fakeHasher struct{}
+
+func (fakeHasher) ComputeHash(password string) string { return "fake." + password }
+
+type fakeValidator struct{ ok bool }
+
+func (v fakeValidator) ValidatePassword(string, string) (bool, bool) { return v.ok, false }
+
+func Test_HashingAndValidating_WithFakes_CanBeInjected(t *testing.T) {
+	// Consumer code which only depends on the interfaces
+	signUp := func(h Hashing, password string) string { return h.ComputeHash(password) }
+	login := func(v Validating, password, storedHash string) bool {
+		ok, _ := v.ValidatePassword(password, storedHash)
+		return ok
+	}
+
+	areEqual(t, "fake.secret", signUp(fakeHasher{}, "secret"))
+	areEqual(t, true, login(fakeValidator{ok: true}, "secret", "fake.secret"))
+	areEqual(t, false, login(fakeValidator{ok: false}, "secret", "fake.secret"))
+
+	// The real types work with the same consumer code
+	hash := signUp(NewHasher(), "Just4Now!2019")
+	areEqual(t, true, login(NewValidator(), "Just4Now!2019", hash))
+}
@@ -0,0 +1,89 @@
+package pwd
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dusted-go/encoding/base62"
+)
+
+// ------------------
+// Hash metadata
+// ------------------
+
+// Prefix of the optional metadata segment which holds the time of hashing
+// as a base62 encoded unix timestamp (e.g. "strategy.salt.hash.t=1m3Kx2").
+// A base64 segment can never contain a "=" in this position, therefore
+// the metadata segment cannot be confused with the hash of a legacy hash.
+const hashedAtPrefix = "t="
+
+// Metadata segment which marks hex encoded salt and hash segments
+// (e.g. "strategy.salt.hash.e=hex"). It precedes the time of hashing.
+const hexEncodingSegment = "e=hex"
+
+// ErrInvalidTimestamp is returned when the metadata segment of a password hash
+// doesn't hold a valid timestamp.
+var ErrInvalidTimestamp = errors.New("metadata must hold a base62 encoded unix timestamp")
+
+// encodeHashedAt encodes the time of hashing into a metadata segment.
+func encodeHashedAt(t time.Time) string {
+	return hashedAtPrefix + encodeBase62(int(t.Unix()))
+}
+
+// decodeHashedAt decodes the time of hashing from a metadata segment.
+func decodeHashedAt(segment string) (time.Time, error) {
+	encoded := strings.TrimPrefix(segment, hashedAtPrefix)
+
+	// Unix timestamps until the year 3700 have at most 6 base62 digits
+	maxDigits := 6
+	if encoded == "" || len(encoded) > maxDigits {
+		return time.Time{}, ErrInvalidTimestamp
+	}
+	for _, r := range encoded {
+		if !strings.ContainsRune(base62Alphabet, r) {
+			return time.Time{}, ErrInvalidTimestamp
+		}
+	}
+	return time.Unix(int64(base62.DecodeToInt(encoded)), 0).UTC(), nil
+}
+
+// WithHashTimestamp makes the Hasher append the time of hashing to every
+// password hash, which can be read back with HashedAt (e.g. to expire old passwords).
+// Hashes in the PHC string format don't carry a timestamp.
+func WithHashTimestamp() HasherOption {
+	return func(h *Hasher) {
+		h.now = time.Now
+	}
+}
+
+// HashedAt returns the time at which a stored password hash was computed.
+// ok is false if the hash doesn't carry a timestamp or cannot be parsed.
+func HashedAt(storedHash string) (hashedAt time.Time, ok bool) {
+	pwdh, err := parsePasswordHash(storedHash)
+	if err != nil || pwdh.hashedAt.IsZero() {
+		return time.Time{}, false
+	}
+	return pwdh.hashedAt, true
+}
+
+// PasswordAgeFunc reports whether the password of a stored hash must be rotated.
+type PasswordAgeFunc = func(storedHash string) (expired bool)
+
+// PasswordAgeCheck creates a check which flags stored password hashes older than max,
+// e.g. to force a password change every 90 days after a successful login.
+// Unlike needsUpgrade the password itself must be changed, not only re-hashed.
+// Hashes without a timestamp (see WithHashTimestamp) are never flagged.
+func PasswordAgeCheck(max time.Duration) PasswordAgeFunc {
+	return passwordAgeCheck(max, time.Now)
+}
+
+func passwordAgeCheck(max time.Duration, now func() time.Time) PasswordAgeFunc {
+	if max <= 0 {
+		panic("max must be greater than zero")
+	}
+	return func(storedHash string) (expired bool) {
+		hashedAt, ok := HashedAt(storedHash)
+		return ok && now().Sub(hashedAt) > max
+	}
+}
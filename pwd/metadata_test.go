@@ -0,0 +1,79 @@
+package pwd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ComputeHash_WithHashTimestamp_AppendsMetadataSegment(t *testing.T) {
+	password := "Just4Now!2019"
+	hashed := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	hasher := NewHasher(WithHashTimestamp())
+	hasher.now = func() time.Time { return hashed }
+
+	hash := hasher.ComputeHash(password)
+
+	areEqual(t, 3, strings.Count(hash, "."))
+	if !strings.Contains(hash, "."+hashedAtPrefix) {
+		t.Error("Hash was expected to carry a timestamp:", hash)
+	}
+	ok, needsUpgrade := NewValidator().ValidatePassword(password, hash)
+	areEqual(t, true, ok)
+	areEqual(t, false, needsUpgrade)
+
+	hashedAt, ok := HashedAt(hash)
+	areEqual(t, true, ok)
+	areEqual(t, hashed, hashedAt)
+}
+
+func Test_HashedAt_WithLegacyHash_ReturnsFalse(t *testing.T) {
+	hash := NewHasher().ComputeHash("Just4Now!2019")
+
+	hashedAt, ok := HashedAt(hash)
+
+	areEqual(t, false, ok)
+	areEqual(t, true, hashedAt.IsZero())
+	if valid, _ := NewValidator().ValidatePassword("Just4Now!2019", hash); !valid {
+		t.Error("Legacy hash without a timestamp was expected to remain valid.")
+	}
+}
+
+func Test_parsePasswordHash_WithDottedStrategyAndTimestamp_ParsesAllSegments(t *testing.T) {
+	pwdh, err := parsePasswordHash("custom/v1.5/A.AQMF.CQUA.t=1m3Kx2")
+
+	areEqual(t, nil, err)
+	areEqual(t, "custom/v1.5/A", pwdh.strategy)
+	areEqual(t, "AQMF", pwdh.base64Salt)
+	areEqual(t, "CQUA", pwdh.base64Hash)
+	areEqual(t, "custom/v1.5/A.AQMF.CQUA.t=1m3Kx2", pwdh.String())
+}
+
+func Test_parsePasswordHash_WithInvalidTimestamp_ReturnsError(t *testing.T) {
+	for _, hash := range []string{
+		"custom/v1.5/A.AQMF.CQUA.t=",
+		"custom/v1.5/A.AQMF.CQUA.t=1m3Kx2abc",
+		"custom/v1.5/A.AQMF.CQUA.t=1-3Kx2",
+	} {
+		if _, err := parsePasswordHash(hash); !errors.Is(err, ErrInvalidTimestamp) {
+			t.Error("Expected:", ErrInvalidTimestamp, "Actual:", err)
+		}
+	}
+}
+
+func Test_PasswordAgeCheck_WithHashOlderThanMax_ReturnsExpired(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	hasher := NewHasher(WithHashTimestamp())
+	check := passwordAgeCheck(90*day, func() time.Time { return now })
+
+	hasher.now = func() time.Time { return now.Add(-100 * day) }
+	areEqual(t, true, check(hasher.ComputeHash("Just4Now!2019")))
+
+	hasher.now = func() time.Time { return now.Add(-10 * day) }
+	areEqual(t, false, check(hasher.ComputeHash("Just4Now!2019")))
+
+	areEqual(t, false, check(NewHasher().ComputeHash("Just4Now!2019")))
+	areEqual(t, false, PasswordAgeCheck(90*day)(NewHasher(WithHashTimestamp()).ComputeHash("Just4Now!2019")))
+}
@@ -0,0 +1,31 @@
+package pwd
+
+import "golang.org/x/text/unicode/norm"
+
+// NormalizePassword applies Unicode NFC normalization to a password.
+//
+// The same character can be encoded as different byte sequences
+// (e.g. a composed "é" or an "e" followed by a combining accent) depending
+// on the platform the password was typed on. Hash and validate the
+// normalized form so that users can log in from any platform.
+//
+// Normalisation must be applied symmetrically at signup and login.
+// Prefer WithNormalizedHashing and WithNormalizedValidation over calling
+// this function manually so it can't be forgotten on one side.
+func NormalizePassword(password string) string {
+	return norm.NFC.String(password)
+}
+
+// WithNormalizedHashing makes the Hasher apply NormalizePassword before hashing.
+func WithNormalizedHashing() HasherOption {
+	return func(h *Hasher) {
+		h.normalize = true
+	}
+}
+
+// WithNormalizedValidation makes the Validator apply NormalizePassword before validating.
+func WithNormalizedValidation() ValidatorOption {
+	return func(v *Validator) {
+		v.normalize = true
+	}
+}
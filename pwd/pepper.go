@@ -0,0 +1,71 @@
+package pwd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+
+	"github.com/dusted-go/security/rng"
+)
+
+// ------------------
+// Pepper
+// ------------------
+
+// applyPepper keys the password with a secret pepper, which is stored outside of
+// the database (e.g. in a secret manager), so that leaked hashes cannot be cracked
+// without it. The password is replaced by its HMAC-SHA256 under the pepper.
+// Without a pepper the password is returned unchanged.
+func applyPepper(pepper []byte, password []byte) []byte {
+	if pepper == nil {
+		return password
+	}
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write(password)
+	return mac.Sum(nil)
+}
+
+// WithPepper makes the Hasher apply a secret pepper to every password before hashing.
+// Hashes computed with a pepper can only be validated by a Validator
+// created with NewValidatorWithPeppers and the same pepper.
+func WithPepper(pepper []byte) HasherOption {
+	if pepper == nil {
+		panic("pepper cannot be nil")
+	}
+	return func(h *Hasher) {
+		h.pepper = pepper
+	}
+}
+
+// NewValidatorWithPeppers creates a new Validator for hashes computed with a pepper
+// (see WithPepper). During a rotation of the pepper the previous pepper is tried
+// when the current one doesn't match, and a match with the previous pepper is
+// reported as needsUpgrade, so that the hash is lazily re-computed with the
+// current pepper on the next successful login. Pass nil as previous once all
+// hashes have been rotated or outside of a rotation.
+// NeedsUpgrade cannot detect hashes with the previous pepper without the password.
+func NewValidatorWithPeppers(current []byte, previous []byte, opts ...ValidatorOption) *Validator {
+	if current == nil {
+		panic("current cannot be nil")
+	}
+	v := NewValidator(opts...)
+	v.pepper = current
+	v.previousPepper = previous
+	return v
+}
+
+// Zeroize overwrites the pepper with zeros.
+// The Hasher keeps a reference to the pepper slice which was passed to it,
+// so callers which rely on this must not hold any other copies of the pepper.
+// The Hasher must not be used after it has been zeroized.
+func (h *Hasher) Zeroize() {
+	rng.Zero(h.pepper)
+}
+
+// Zeroize overwrites the current and previous peppers with zeros.
+// The Validator keeps references to the pepper slices which were passed to it,
+// so callers which rely on this must not hold any other copies of the peppers.
+// The Validator must not be used after it has been zeroized.
+func (v *Validator) Zeroize() {
+	rng.Zero(v.pepper)
+	rng.Zero(v.previousPepper)
+}
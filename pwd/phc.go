@@ -0,0 +1,231 @@
+package pwd
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ------------------
+// PHC string format
+// ------------------
+
+// The PHC string format stores a password hash as
+// $<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*]$<salt>$<hash>
+// with salt and hash encoded as unpadded standard base64.
+// See https://github.com/P-H-C/phc-string-format/blob/master/phc-sf-spec.md
+
+// Encoding of the salt and hash segments of a PHC string.
+var phcEncoding = base64.RawStdEncoding
+
+// Python's passlib (pbkdf2_sha256) stores PBKDF2 hashes in its own variant
+// $pbkdf2-sha256$<rounds>$<salt>$<hash> with the rounds instead of named parameters,
+// and salt and hash encoded as unpadded base64 with "." instead of "+".
+var passlibEncoding = base64.NewEncoding(
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789./").WithPadding(base64.NoPadding)
+
+// isPasslibRounds reports whether the parameter segment of a PBKDF2 PHC string
+// is the bare number of rounds of the passlib variant.
+func isPasslibRounds(paramSegment string) bool {
+	if paramSegment == "" {
+		return false
+	}
+	for _, r := range paramSegment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrUnsupportedPHC is returned when a PHC string uses an unknown algorithm or parameters.
+var ErrUnsupportedPHC = errors.New("unsupported PHC algorithm or parameters")
+
+// Version of Argon2 which is implemented by golang.org/x/crypto/argon2.
+const argon2Version = "19"
+
+// phcHeader converts a strategy into the "$<id>[$v=<version>]$<params>" header of a PHC string.
+func phcHeader(strategy string) (string, error) {
+	name := strings.SplitN(strategy, "/", 2)[0]
+	switch name {
+	case "pbkdf2":
+		params, err := parsePbkdf2Strategy(strategy)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrUnsupportedPHC, err)
+		}
+		return fmt.Sprintf("$pbkdf2-sha256$i=%d,l=%d", params.iterations, params.hashLength), nil
+	case "argon2id":
+		params, err := parseArgon2Strategy(strategy)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrUnsupportedPHC, err)
+		}
+		return fmt.Sprintf(
+			"$argon2id$v=%s$m=%d,t=%d,p=%d",
+			argon2Version,
+			params.memoryKiB,
+			params.iterations,
+			params.threads), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedPHC, name)
+	}
+}
+
+// parsePHCParams parses the comma separated "<param>=<value>" segment of a PHC string
+// into positive integers. Only the given parameter names are accepted.
+func parsePHCParams(segment string, names ...string) (map[string]int, error) {
+	params := make(map[string]int)
+	for _, pair := range strings.Split(segment, ",") {
+		name, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("%w: parameter %q has no value", ErrUnsupportedPHC, pair)
+		}
+		known := false
+		for _, n := range names {
+			known = known || n == name
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: unknown parameter %q", ErrUnsupportedPHC, name)
+		}
+		if _, ok := params[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate parameter %q", ErrUnsupportedPHC, name)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%w: parameter %q must be a positive integer", ErrUnsupportedPHC, name)
+		}
+		params[name] = n
+	}
+	return params, nil
+}
+
+// phcStrategy converts the algorithm, version and parameters of a PHC string into a strategy.
+func phcStrategy(id string, version string, paramSegment string, hash []byte) (string, error) {
+	switch {
+	case id == "pbkdf2-sha256" && version == "":
+		return phcPbkdf2Strategy(paramSegment, hash)
+	case id == "argon2id" && version == argon2Version:
+		return phcArgon2idStrategy(paramSegment, hash)
+	default:
+		return "", fmt.Errorf("%w: %q version %q", ErrUnsupportedPHC, id, version)
+	}
+}
+
+// phcPbkdf2Strategy converts the parameters of a PBKDF2 PHC string into a strategy.
+// The hash length defaults to the length of the decoded hash if it is not given.
+func phcPbkdf2Strategy(paramSegment string, hash []byte) (string, error) {
+	params, err := parsePHCParams(paramSegment, "i", "l")
+	if err != nil {
+		return "", err
+	}
+	iterations, ok := params["i"]
+	if !ok {
+		return "", fmt.Errorf("%w: missing iterations", ErrUnsupportedPHC)
+	}
+	if iterations > maxPbkdf2Iterations {
+		return "", fmt.Errorf("%w: iterations must not exceed %d", ErrUnsupportedPHC, maxPbkdf2Iterations)
+	}
+	hashLength, ok := params["l"]
+	if !ok {
+		hashLength = len(hash)
+	}
+	if hashLength > maxPbkdf2HashLength {
+		return "", fmt.Errorf("%w: hash length must not exceed %d bytes", ErrUnsupportedPHC, maxPbkdf2HashLength)
+	}
+	return pbkdf2Strategy(hashLength, iterations), nil
+}
+
+// phcArgon2idStrategy converts the parameters of an Argon2id PHC string into a strategy.
+// The hash length is always the length of the decoded hash.
+func phcArgon2idStrategy(paramSegment string, hash []byte) (string, error) {
+	values, err := parsePHCParams(paramSegment, "m", "t", "p")
+	if err != nil {
+		return "", err
+	}
+	for _, name := range []string{"m", "t", "p"} {
+		if _, ok := values[name]; !ok {
+			return "", fmt.Errorf("%w: missing parameter %q", ErrUnsupportedPHC, name)
+		}
+	}
+	params := &argon2Params{
+		memoryKiB:  values["m"],
+		iterations: values["t"],
+		threads:    values["p"],
+		hashLength: len(hash)}
+	if err := checkArgon2Params(params); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnsupportedPHC, err)
+	}
+	return Argon2idStrategy(params.memoryKiB, params.iterations, params.threads, params.hashLength), nil
+}
+
+// parsePHCHash parses a password hash in the PHC string format.
+func parsePHCHash(pwdh string) (*passwordHash, error) {
+	invalidPwdh := func(reason error) error {
+		return fmt.Errorf("string is not a valid PHC passwordHash: %v: %w", pwdh, reason)
+	}
+
+	// 1. A PHC string starts with a "$" followed by the id, an optional version,
+	// the params, salt and hash
+	parts := strings.Split(pwdh, "$")
+	if len(parts) < 5 || len(parts) > 6 || parts[0] != "" {
+		return nil, invalidPwdh(ErrInvalidPartCount)
+	}
+	id, version, paramSegment := parts[1], "", parts[2]
+	if len(parts) == 6 {
+		if !strings.HasPrefix(parts[2], "v=") {
+			return nil, invalidPwdh(ErrInvalidPartCount)
+		}
+		version, paramSegment = strings.TrimPrefix(parts[2], "v="), parts[3]
+	}
+	header := strings.Join(parts[:len(parts)-2], "$")
+	encSalt, encHash := parts[len(parts)-2], parts[len(parts)-1]
+	if id == "" || encSalt == "" || encHash == "" {
+		return nil, invalidPwdh(ErrInvalidPartCount)
+	}
+
+	// 2. Decode the salt and hash, which passlib encodes differently
+	encoding := phcEncoding
+	if id == "pbkdf2-sha256" && version == "" && isPasslibRounds(paramSegment) {
+		encoding = passlibEncoding
+		paramSegment = "i=" + paramSegment
+	}
+	salt, err := encoding.DecodeString(encSalt)
+	if err != nil {
+		return nil, invalidPwdh(fmt.Errorf("%w: %w", ErrInvalidSalt, err))
+	}
+	hash, err := encoding.DecodeString(encHash)
+	if err != nil {
+		return nil, invalidPwdh(fmt.Errorf("%w: %w", ErrInvalidHash, err))
+	}
+
+	// 3. Convert the algorithm and parameters into a strategy
+	strategy, err := phcStrategy(id, version, paramSegment, hash)
+	if err != nil {
+		return nil, invalidPwdh(err)
+	}
+
+	return &passwordHash{
+		salt:       salt,
+		hash:       hash,
+		strategy:   strategy,
+		base64Salt: encSalt,
+		base64Hash: encHash,
+		phcHeader:  header}, nil
+}
+
+// NewPHCHasher creates a new Hasher which emits password hashes in the PHC string
+// format (e.g. "$pbkdf2-sha256$i=10000,l=32$<salt>$<hash>") for interoperability
+// with other PHC-aware systems. Use WithStrategy and Argon2idStrategy to emit
+// "$argon2id$v=19$m=...,t=...,p=...$<salt>$<hash>" instead.
+// The Validator accepts PHC strings without any further configuration, including
+// the $pbkdf2-sha256$<rounds>$<salt>$<hash> variant of Python's passlib.
+func NewPHCHasher(opts ...HasherOption) *Hasher {
+	h := NewHasher(opts...)
+	header, err := phcHeader(h.strategy)
+	if err != nil {
+		panic(fmt.Errorf("failed to create a PHC hasher: %w", err))
+	}
+	h.phcHeader = header
+	return h
+}
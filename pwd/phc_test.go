@@ -0,0 +1,147 @@
+package pwd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_NewPHCHasher_RoundTrip_ReturnsPHCString(t *testing.T) {
+	password := "Correct Horse 1!"
+	hasher := NewPHCHasher()
+	validator := NewValidator()
+
+	hash := hasher.ComputeHash(password)
+
+	if !strings.HasPrefix(hash, "$pbkdf2-sha256$i=100000,l=64$") {
+		t.Error("Hash was expected to be a PHC string of the default strategy:", hash)
+	}
+	ok, needsUpgrade := validator.ValidatePassword(password, hash)
+	areEqual(t, true, ok)
+	areEqual(t, false, needsUpgrade)
+
+	ok, _ = validator.ValidatePassword("Wrong Horse 1!", hash)
+	areEqual(t, false, ok)
+}
+
+func Test_ValidatePassword_WithReferencePHCString_ReturnsTrue(t *testing.T) {
+	// Generated with Python's hashlib.pbkdf2_hmac and encoded as a PHC string by hand,
+	// in the layout of the PHC format specification
+	hash := "$pbkdf2-sha256$i=10000,l=32$cmVmZXJlbmNlLXNhbHQtMTY$JwafcAQgDJAx34tOc7f2AYXy5l86NpOrO58rUxrNFic"
+	validator := NewValidator()
+
+	ok, needsUpgrade := validator.ValidatePassword("Correct Horse 1!", hash)
+	areEqual(t, true, ok)
+	areEqual(t, true, needsUpgrade)
+
+	ok, _ = validator.ValidatePassword("Correct Horse 2!", hash)
+	areEqual(t, false, ok)
+
+	// The hash length defaults to the length of the hash
+	ok, _ = validator.ValidatePassword("Correct Horse 1!", strings.Replace(hash, ",l=32", "", 1))
+	areEqual(t, true, ok)
+
+	strategy, err := HashStrategy(hash)
+	areEqual(t, nil, err)
+	areEqual(t, pbkdf2Strategy(32, 10000), strategy)
+}
+
+func Test_ValidatePassword_WithPasslibPbkdf2String_ReturnsTrue(t *testing.T) {
+	// Known-correct hash of the passlib pbkdf2_sha256 test suite, the salt and hash
+	// contain the "." and "/" characters of its base64 variant
+	hash := "$pbkdf2-sha256$1212$4vjV83LKPjQzk31VI4E0Vw$hsYF68OiOUPdDZ1Fg.fJPeq1h/gXXY7acBp9/6c.tmQ"
+	validator := NewValidator()
+
+	ok, needsUpgrade := validator.ValidatePassword("password", hash)
+	areEqual(t, true, ok)
+	areEqual(t, true, needsUpgrade)
+
+	ok, _ = validator.ValidatePassword("Password", hash)
+	areEqual(t, false, ok)
+
+	strategy, err := HashStrategy(hash)
+	areEqual(t, nil, err)
+	areEqual(t, pbkdf2Strategy(32, 1212), strategy)
+
+	pwdh, err := parsePasswordHash(hash)
+	areEqual(t, nil, err)
+	areEqual(t, hash, pwdh.String())
+}
+
+func Test_parsePasswordHash_WithInvalidPHCString_ReturnsError(t *testing.T) {
+	for _, hash := range []string{
+		"$pbkdf2-sha256$i=10000$c2FsdA",
+		"$pbkdf2-sha512$i=10000$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=0$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=-1$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$l=32$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=1,i=2$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=1,m=2$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=10000$c2FsdA==$aGFzaA",
+		"$pbkdf2-sha256$i=10000$c2FsdA$",
+		"$pbkdf2-sha256$0$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$1212$c2Fsd+A$aGFzaA",
+		"$pbkdf2-sha512$25000$c2FsdA$aGFzaA",
+	} {
+		if _, err := parsePasswordHash(hash); err == nil {
+			t.Error("PHC string was expected to be invalid:", hash)
+		}
+	}
+
+	_, err := parsePasswordHash("$argon2d$v=19$m=65536,t=3,p=4$c2FsdA$aGFzaA")
+	if !errors.Is(err, ErrUnsupportedPHC) {
+		t.Error("Expected:", ErrUnsupportedPHC, "Actual:", err)
+	}
+}
+
+func Test_ValidatePassword_WithReferenceArgon2idPHCString_ReturnsTrue(t *testing.T) {
+	// Test vector of the Argon2 reference implementation
+	hash := "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+	validator := NewValidator()
+
+	ok, _ := validator.ValidatePassword("password", hash)
+	areEqual(t, true, ok)
+
+	ok, _ = validator.ValidatePassword("Password", hash)
+	areEqual(t, false, ok)
+
+	strategy, err := HashStrategy(hash)
+	areEqual(t, nil, err)
+	areEqual(t, Argon2idStrategy(65536, 2, 1, 32), strategy)
+}
+
+func Test_NewPHCHasher_WithArgon2idStrategy_RoundTrip(t *testing.T) {
+	password := "Correct Horse 1!"
+	strategy := Argon2idStrategy(64, 1, 2, 16)
+	hasher := NewPHCHasher(WithInsecureStrategy(strategy))
+	validator := NewValidator()
+
+	hash := hasher.ComputeHash(password)
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=2$") {
+		t.Error("Hash was expected to be an Argon2id PHC string:", hash)
+	}
+	ok, _ := validator.ValidatePassword(password, hash)
+	areEqual(t, true, ok)
+
+	pwdh, err := parsePasswordHash(hash)
+	areEqual(t, nil, err)
+	areEqual(t, strategy, pwdh.strategy)
+	areEqual(t, hash, pwdh.String())
+}
+
+func Test_parsePasswordHash_WithInvalidArgon2idPHCString_ReturnsError(t *testing.T) {
+	for _, hash := range []string{
+		"$argon2id$m=64,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=16$m=64,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=64,t=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=4,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=4194304,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=64,t=1,p=256$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ$CTFh",
+	} {
+		if _, err := parsePasswordHash(hash); err == nil {
+			t.Error("PHC string was expected to be invalid:", hash)
+		}
+	}
+}
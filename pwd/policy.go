@@ -1,15 +1,23 @@
 package pwd
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"strings"
 	"unicode"
 )
 
 // PolicyFunc validates a password against a set of rules.
 type PolicyFunc = func(password string) (ok bool, errMsgs []string)
 
+// PolicyFuncCtx validates a password against a set of rules which may
+// require I/O (e.g. a breached password lookup) and must respect the context.
+type PolicyFuncCtx = func(ctx context.Context, password string) (ok bool, errMsgs []string)
+
 type matchFunc = func(rune) bool
 type validateFunc = func(password string) (ok bool, errMsg string)
+type validateFuncCtx = func(ctx context.Context, password string) (ok bool, errMsg string)
 
 func genericValidateFunc(match matchFunc, minCount int, group string) validateFunc {
 	return func(password string) (ok bool, errMsg string) {
@@ -56,22 +64,21 @@ func DigitsCheck(minCount int) validateFunc {
 	return genericValidateFunc(unicode.IsDigit, minCount, group)
 }
 
+// Characters which count as special characters.
+const specialChars = "!@£$%^&*()_-+={}[]€#:;\"'|\\?/<>,.~`§±"
+
+// isSpecialChar reports whether a rune is one of the special characters.
+func isSpecialChar(r rune) bool {
+	return strings.ContainsRune(specialChars, r)
+}
+
 // SpecialCharCheck validates that a password to contains special characters.
 func SpecialCharCheck(minCount int) validateFunc {
-	special := "!@£$%^&*()_-+={}[]€#:;\"'|\\?/<>,.~`§±"
-	check := func(r rune) bool {
-		for _, c := range special {
-			if r == c {
-				return true
-			}
-		}
-		return false
-	}
 	group := "special character"
 	if minCount > 1 {
 		group += "s"
 	}
-	return genericValidateFunc(check, minCount, group)
+	return genericValidateFunc(isSpecialChar, minCount, group)
 }
 
 // LengthCheck validates that a password meets a minimum length.
@@ -84,6 +91,167 @@ func LengthCheck(minLength int) validateFunc {
 	}
 }
 
+// MaxLengthCheck validates that a password doesn't exceed a maximum length.
+func MaxLengthCheck(maxLength int) validateFunc {
+	return func(password string) (ok bool, errMsg string) {
+		if len(password) > maxLength {
+			return false, fmt.Sprintf("Password exceeds the maximum length of %v characters", maxLength)
+		}
+		return true, ""
+	}
+}
+
+// RegexCheck validates a password against a custom regular expression.
+// If mustMatch is true the password must match the pattern, otherwise it must not match it.
+// errMsg is returned when the check fails.
+func RegexCheck(pattern *regexp.Regexp, mustMatch bool, errMsg string) validateFunc {
+	if pattern == nil {
+		panic("pattern cannot be nil")
+	}
+	return func(password string) (ok bool, msg string) {
+		if pattern.MatchString(password) != mustMatch {
+			return false, errMsg
+		}
+		return true, ""
+	}
+}
+
+// NoLeadingTrailingSpaceCheck validates that a password doesn't start or end with whitespace.
+func NoLeadingTrailingSpaceCheck() validateFunc {
+	return func(password string) (ok bool, errMsg string) {
+		if password != strings.TrimSpace(password) {
+			return false, "Password must not start or end with whitespace"
+		}
+		return true, ""
+	}
+}
+
+// NotReused validates that a new password doesn't match any of the given old
+// password hashes, e.g. to prevent the reuse of the last N passwords.
+// Old hashes may use different strategies, which the validator resolves per hash.
+// The comparison is not a login attempt, therefore it neither invokes the validation
+// hook nor pads the duration with WithMinValidationTime.
+func NotReused(validator *Validator, oldHashes []string) validateFunc {
+	if validator == nil {
+		panic("validator cannot be nil.")
+	}
+	return func(password string) (ok bool, errMsg string) {
+		if reused, _, _ := validator.validateAny(password, oldHashes); reused {
+			return false, "Password must not be the same as a previous password"
+		}
+		return true, ""
+	}
+}
+
+// Common leetspeak substitutions of lowercase letters.
+var leetSubstitutions = map[rune]string{
+	'a': "@4",
+	'b': "8",
+	'e': "3",
+	'g': "96",
+	'i': "1!|",
+	'l': "1|",
+	'o': "0",
+	's': "$5",
+	't': "7+",
+	'z': "2",
+}
+
+// isVariationOf reports whether a password starts with the lowercase base word
+// after undoing capitalisation and leetspeak substitutions and continues with
+// nothing but digits and special characters (e.g. "P@ssw0rd2024!" for "password").
+func isVariationOf(password string, base []rune) bool {
+	runes := []rune(password)
+	if len(runes) < len(base) {
+		return false
+	}
+	for i, b := range base {
+		r := unicode.ToLower(runes[i])
+		if r != b && !strings.ContainsRune(leetSubstitutions[b], r) {
+			return false
+		}
+	}
+	for _, r := range runes[len(base):] {
+		if !unicode.IsDigit(r) && !isSpecialChar(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ForbidVariationsCheck validates that a password isn't a common variation of a
+// base word such as the site or brand name: any capitalisation, leetspeak
+// substitutions (e.g. "@" for "a", "0" for "o") and appended digits, years or
+// special characters (e.g. "MySiteName2024!"). Instead of generating every
+// variation, which grows exponentially with the length of the word, the password
+// is compared character by character, which takes linear time.
+func ForbidVariationsCheck(base string) validateFunc {
+	if base == "" {
+		panic("base cannot be empty")
+	}
+	lowerBase := []rune(strings.ToLower(base))
+	return func(password string) (ok bool, errMsg string) {
+		if isVariationOf(password, lowerBase) {
+			return false, fmt.Sprintf("Password must not be a variation of %v", base)
+		}
+		return true, ""
+	}
+}
+
+// Scripts whose letters are commonly confused with each other (e.g. Latin "a" and Cyrillic "а").
+var confusableScripts = []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek}
+
+// MixedScriptCheck validates that the letters of a password don't mix several of the
+// given scripts, which would allow homoglyph confusion (e.g. a Latin password with a
+// Cyrillic "а"). Without any scripts Latin, Cyrillic and Greek are checked.
+// Digits, special characters and letters of other scripts are not restricted.
+func MixedScriptCheck(scripts ...*unicode.RangeTable) validateFunc {
+	if len(scripts) == 0 {
+		scripts = confusableScripts
+	}
+	return func(password string) (ok bool, errMsg string) {
+		used := 0
+		for _, script := range scripts {
+			for _, r := range password {
+				if unicode.Is(script, r) {
+					used++
+					break
+				}
+			}
+		}
+		if used > 1 {
+			return false, "Password must not mix letters of different scripts"
+		}
+		return true, ""
+	}
+}
+
+// AllowedScriptsCheck validates that all letters of a password belong to one of
+// the given scripts (e.g. unicode.Latin), which rejects passwords made of
+// homoglyphs from other scripts. Digits and special characters are always allowed.
+func AllowedScriptsCheck(scripts ...*unicode.RangeTable) validateFunc {
+	if len(scripts) == 0 {
+		panic("scripts cannot be empty")
+	}
+	return func(password string) (ok bool, errMsg string) {
+		for _, r := range password {
+			if unicode.IsLetter(r) && !unicode.In(r, scripts...) {
+				return false, "Password must only contain letters of the allowed scripts"
+			}
+		}
+		return true, ""
+	}
+}
+
+// TrimPassword removes leading and trailing whitespace from a password.
+//
+// Normalisation must be applied symmetrically: if it's applied before hashing
+// a password at signup it must also be applied before validating the password
+// at login (and vice versa), otherwise correct passwords will be rejected.
+func TrimPassword(password string) string {
+	return strings.TrimSpace(password)
+}
+
 // Policy combines multiple different password validation functions into a single `PolicyFunc`.
 func Policy(funcs ...validateFunc) PolicyFunc {
 	return func(password string) (ok bool, errMsgs []string) {
@@ -96,11 +264,159 @@ func Policy(funcs ...validateFunc) PolicyFunc {
 	}
 }
 
-// DefaultPolicy creates the default password policy.
-var DefaultPolicy = Policy(
-	LengthCheck(8),
-	UpperCaseCheck(1),
-	LowerCaseCheck(1),
-	DigitsCheck(1),
-	SpecialCharCheck(1),
-)
+// PolicyFailFast combines multiple password validation functions into a single `PolicyFunc`
+// which stops at the first failing check and returns only its error message.
+// Checks run in the given order, so cheap checks should come before expensive ones.
+func PolicyFailFast(funcs ...validateFunc) PolicyFunc {
+	return func(password string) (ok bool, errMsgs []string) {
+		for _, f := range funcs {
+			if ok, errMsg := f(password); !ok {
+				return false, []string{errMsg}
+			}
+		}
+		return true, nil
+	}
+}
+
+// IgnoreContext adapts a CPU-bound validation function for use with PolicyCtx.
+func IgnoreContext(f validateFunc) validateFuncCtx {
+	return func(_ context.Context, password string) (ok bool, errMsg string) {
+		return f(password)
+	}
+}
+
+// PolicyCtx combines multiple context-aware password validation functions
+// into a single `PolicyFuncCtx`. The context is passed to every check.
+// If the context is done the remaining checks are skipped and the
+// password is rejected, because it couldn't be fully validated.
+func PolicyCtx(funcs ...validateFuncCtx) PolicyFuncCtx {
+	return func(ctx context.Context, password string) (ok bool, errMsgs []string) {
+		for _, f := range funcs {
+			if err := ctx.Err(); err != nil {
+				errMsgs = append(errMsgs, fmt.Sprintf("Password could not be validated: %v", err))
+				return false, errMsgs
+			}
+			if ok, errMsg := f(ctx, password); !ok {
+				errMsgs = append(errMsgs, errMsg)
+			}
+		}
+		return len(errMsgs) == 0, errMsgs
+	}
+}
+
+// DefaultPolicy validates a password against the default password policy.
+// Use NewPolicy to create a customised policy.
+func DefaultPolicy(password string) (ok bool, errMsgs []string) {
+	return defaultPolicy(password)
+}
+
+// The default password policy.
+var defaultPolicy = NewPolicy()
+
+// PolicyConfig declares the rules of a password policy.
+// Rules with a zero value are not enforced.
+type PolicyConfig struct {
+	MinLength  int
+	MaxLength  int
+	MinUpper   int
+	MinLower   int
+	MinDigits  int
+	MinSpecial int
+
+	// Additional checks which can only be added via WithChecks.
+	checks []validateFunc
+}
+
+// PolicyOption customises the rules of a password policy created by NewPolicy.
+type PolicyOption = func(cfg *PolicyConfig)
+
+// WithMinLength sets the minimum length of a password.
+func WithMinLength(minLength int) PolicyOption {
+	return func(cfg *PolicyConfig) { cfg.MinLength = minLength }
+}
+
+// WithMaxLength sets the maximum length of a password.
+func WithMaxLength(maxLength int) PolicyOption {
+	return func(cfg *PolicyConfig) { cfg.MaxLength = maxLength }
+}
+
+// WithMinUpper sets the minimum number of uppercase letters.
+func WithMinUpper(minCount int) PolicyOption {
+	return func(cfg *PolicyConfig) { cfg.MinUpper = minCount }
+}
+
+// WithMinLower sets the minimum number of lowercase letters.
+func WithMinLower(minCount int) PolicyOption {
+	return func(cfg *PolicyConfig) { cfg.MinLower = minCount }
+}
+
+// WithMinDigits sets the minimum number of digits.
+func WithMinDigits(minCount int) PolicyOption {
+	return func(cfg *PolicyConfig) { cfg.MinDigits = minCount }
+}
+
+// WithMinSpecial sets the minimum number of special characters.
+func WithMinSpecial(minCount int) PolicyOption {
+	return func(cfg *PolicyConfig) { cfg.MinSpecial = minCount }
+}
+
+// WithChecks adds custom validation functions to the policy.
+func WithChecks(funcs ...validateFunc) PolicyOption {
+	return func(cfg *PolicyConfig) { cfg.checks = append(cfg.checks, funcs...) }
+}
+
+// NewPolicy creates a new password policy which starts from the default rules
+// (at least 8 characters, one uppercase letter, one lowercase letter,
+// one digit and one special character) and applies the given options.
+// Every call returns an independent policy.
+func NewPolicy(opts ...PolicyOption) PolicyFunc {
+	cfg := PolicyConfig{
+		MinLength:  8,
+		MinUpper:   1,
+		MinLower:   1,
+		MinDigits:  1,
+		MinSpecial: 1,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return PolicyFromConfig(cfg)
+}
+
+// PolicyFromConfig creates a password policy from a declarative config.
+func PolicyFromConfig(cfg PolicyConfig) PolicyFunc {
+	var funcs []validateFunc
+	if cfg.MinLength > 0 {
+		funcs = append(funcs, LengthCheck(cfg.MinLength))
+	}
+	if cfg.MaxLength > 0 {
+		funcs = append(funcs, MaxLengthCheck(cfg.MaxLength))
+	}
+	if cfg.MinUpper > 0 {
+		funcs = append(funcs, UpperCaseCheck(cfg.MinUpper))
+	}
+	if cfg.MinLower > 0 {
+		funcs = append(funcs, LowerCaseCheck(cfg.MinLower))
+	}
+	if cfg.MinDigits > 0 {
+		funcs = append(funcs, DigitsCheck(cfg.MinDigits))
+	}
+	if cfg.MinSpecial > 0 {
+		funcs = append(funcs, SpecialCharCheck(cfg.MinSpecial))
+	}
+	funcs = append(funcs, cfg.checks...)
+	return Policy(funcs...)
+}
+
+// ClassPolicy creates a password policy from a minimum length and the minimum
+// number of characters of each class in a single call.
+// A minimum of zero skips the check for that class.
+func ClassPolicy(minLength, minUpper, minLower, minDigits, minSpecial int) PolicyFunc {
+	return PolicyFromConfig(PolicyConfig{
+		MinLength:  minLength,
+		MinUpper:   minUpper,
+		MinLower:   minLower,
+		MinDigits:  minDigits,
+		MinSpecial: minSpecial,
+	})
+}
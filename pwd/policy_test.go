@@ -1,6 +1,14 @@
 package pwd
 
-import "testing"
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/dusted-go/security/rng"
+)
 
 func Test_SpecialCharCheck(t *testing.T) {
 	symbols := "!@£$%^&*()_-+={}[]€#:;\"'|\\?/<>,.~`§±"
@@ -54,3 +62,302 @@ func Test_Unhappy(t *testing.T) {
 		}
 	}
 }
+
+func Test_PolicyFromConfig_WithMinUpper_RejectsSingleUppercase(t *testing.T) {
+	policyCheck := PolicyFromConfig(PolicyConfig{
+		MinLength: 8,
+		MinUpper:  2,
+	})
+
+	if ok, _ := policyCheck("Just4Now"); !ok {
+		t.Error("Password was expected to pass the password policy check: Just4Now")
+	}
+	if ok, _ := policyCheck("Just4now"); ok {
+		t.Error("Password was expected to violate the password policy: Just4now")
+	}
+}
+
+func Test_PolicyFromConfig_WithMaxLength_RejectsLongPassword(t *testing.T) {
+	policyCheck := PolicyFromConfig(PolicyConfig{MaxLength: 8})
+
+	if ok, _ := policyCheck("Just4Now!"); ok {
+		t.Error("Password was expected to violate the password policy: Just4Now!")
+	}
+}
+
+func Test_NewPolicy_WithDifferentOptions_DoesNotInterfere(t *testing.T) {
+	password := "Just4Now!"
+
+	strict := NewPolicy(WithMinLength(12))
+	lenient := NewPolicy(WithMinSpecial(0))
+
+	if ok, _ := strict(password); ok {
+		t.Error("Password was expected to violate the strict password policy:", password)
+	}
+	if ok, _ := lenient("Just4Now"); !ok {
+		t.Error("Password was expected to pass the lenient password policy: Just4Now")
+	}
+	if ok, _ := DefaultPolicy(password); !ok {
+		t.Error("Password was expected to pass the default password policy:", password)
+	}
+	if ok, _ := DefaultPolicy("Just4Now"); ok {
+		t.Error("Password was expected to violate the default password policy: Just4Now")
+	}
+}
+
+func Test_NoLeadingTrailingSpaceCheck(t *testing.T) {
+	check := NoLeadingTrailingSpaceCheck()
+
+	for _, pw := range []string{" Just4Now", "Just4Now ", "\tJust4Now\n"} {
+		if ok, _ := check(pw); ok {
+			t.Error("Password was expected to violate the whitespace check:", pw)
+		}
+	}
+	if ok, _ := check("Just 4 Now"); !ok {
+		t.Error("Password was expected to pass the whitespace check: Just 4 Now")
+	}
+}
+
+func Test_TrimPassword(t *testing.T) {
+	if actual := TrimPassword(" \tJust 4 Now\n "); actual != "Just 4 Now" {
+		t.Error("Expected:", "Just 4 Now", "Actual:", actual)
+	}
+}
+
+func Test_RegexCheck_WithRequiredPattern(t *testing.T) {
+	check := RegexCheck(regexp.MustCompile("[#~]"), true, "Password must contain # or ~")
+
+	if ok, _ := check("Just4Now#"); !ok {
+		t.Error("Password was expected to pass the regex check: Just4Now#")
+	}
+	ok, errMsg := check("Just4Now!")
+	if ok {
+		t.Error("Password was expected to violate the regex check: Just4Now!")
+	}
+	if errMsg != "Password must contain # or ~" {
+		t.Error("Unexpected error message:", errMsg)
+	}
+}
+
+func Test_RegexCheck_WithForbiddenPattern(t *testing.T) {
+	check := RegexCheck(regexp.MustCompile("(?i)acme"), false, "Password must not contain the company name")
+
+	if ok, _ := check("Just4Now!"); !ok {
+		t.Error("Password was expected to pass the regex check: Just4Now!")
+	}
+	if ok, _ := check("ACME4Now!"); ok {
+		t.Error("Password was expected to violate the regex check: ACME4Now!")
+	}
+}
+
+func Test_PolicyFailFast_StopsAtFirstFailure(t *testing.T) {
+	calls := 0
+	expensiveCheck := func(password string) (bool, string) {
+		calls++
+		return true, ""
+	}
+
+	policyCheck := PolicyFailFast(
+		LengthCheck(10),
+		expensiveCheck,
+	)
+
+	ok, errMsgs := policyCheck("Short1!")
+
+	if ok {
+		t.Error("Password was expected to violate the password policy: Short1!")
+	}
+	if len(errMsgs) != 1 {
+		t.Error("Expected a single error message. Actual:", errMsgs)
+	}
+	if calls != 0 {
+		t.Error("Expensive check was not expected to be invoked. Calls:", calls)
+	}
+
+	if ok, _ := policyCheck("LongEnough1!"); !ok {
+		t.Error("Password was expected to pass the password policy: LongEnough1!")
+	}
+	if calls != 1 {
+		t.Error("Expensive check was expected to be invoked once. Calls:", calls)
+	}
+}
+
+func Test_PolicyCtx_WithCancelledContext_AbortsNetworkCheck(t *testing.T) {
+	networkCheck := func(ctx context.Context, password string) (bool, string) {
+		select {
+		case <-ctx.Done():
+			return false, "Password could not be checked: " + ctx.Err().Error()
+		case <-time.After(10 * time.Second):
+			return true, ""
+		}
+	}
+
+	policyCheck := PolicyCtx(
+		IgnoreContext(LengthCheck(8)),
+		networkCheck,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	ok, errMsgs := policyCheck(ctx, "Just4Now!")
+	elapsed := time.Since(start)
+
+	if ok {
+		t.Error("Password was expected to be rejected when the context is cancelled.")
+	}
+	if len(errMsgs) != 1 {
+		t.Error("Expected a single error message. Actual:", errMsgs)
+	}
+	if elapsed > time.Second {
+		t.Error("Network check was expected to abort promptly. Elapsed:", elapsed)
+	}
+}
+
+func Test_PolicyCtx_WithDoneContext_SkipsChecks(t *testing.T) {
+	calls := 0
+	check := func(ctx context.Context, password string) (bool, string) {
+		calls++
+		return true, ""
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if ok, _ := PolicyCtx(check)(ctx, "Just4Now!"); ok {
+		t.Error("Password was expected to be rejected when the context is done.")
+	}
+	if calls != 0 {
+		t.Error("Check was not expected to be invoked. Calls:", calls)
+	}
+}
+
+func Test_ClassPolicy_WithZeroMinimum_SkipsOnlyThatClass(t *testing.T) {
+	policyCheck := ClassPolicy(8, 1, 1, 0, 1)
+
+	if ok, errMsgs := policyCheck("JustNow!"); !ok {
+		t.Error("Password without digits was expected to pass the password policy check:", errMsgs)
+	}
+	if ok, _ := policyCheck("justnow!"); ok {
+		t.Error("Password was expected to violate the password policy: justnow!")
+	}
+	if ok, _ := policyCheck("JustNow1"); ok {
+		t.Error("Password was expected to violate the password policy: JustNow1")
+	}
+	if ok, _ := policyCheck("JusNow!"); ok {
+		t.Error("Password was expected to violate the password policy: JusNow!")
+	}
+}
+
+func Test_NotReused_WithOldHashes_RejectsReusedPassword(t *testing.T) {
+	legacyHasher := newHasher(rng.GenerateBytes, createPasswordHashingStrategy, pbkdf2Strategy(32, 1000))
+	oldHashes := []string{
+		NewHasher().ComputeHash("Old1Password!"),
+		legacyHasher.ComputeHash("Old2Password!"),
+	}
+	check := NotReused(NewValidator(), oldHashes)
+
+	for _, password := range []string{"Old1Password!", "Old2Password!"} {
+		if ok, _ := check(password); ok {
+			t.Error("Password was expected to be rejected as reused:", password)
+		}
+	}
+	if ok, errMsg := check("New1Password!"); !ok {
+		t.Error("Password was expected to pass the reuse check:", errMsg)
+	}
+}
+
+func Test_NotReused_WithValidationHookAndMinValidationTime_DoesNotInvokeHookOrPad(t *testing.T) {
+	events := 0
+	validator := NewValidator(
+		WithValidationHook(func(event ValidationEvent) { events++ }),
+		WithMinValidationTime(time.Minute))
+	check := NotReused(validator, []string{NewHasher().ComputeHash("Old1Password!")})
+
+	start := time.Now()
+	if ok, _ := check("Old1Password!"); ok {
+		t.Error("Password was expected to be rejected as reused.")
+	}
+	if ok, errMsg := check("New1Password!"); !ok {
+		t.Error("Password was expected to pass the reuse check:", errMsg)
+	}
+	if events != 0 {
+		t.Error("Expected:", 0, "Actual:", events)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Minute {
+		t.Error("Reuse check was not expected to be padded:", elapsed)
+	}
+}
+
+func Test_ForbidVariationsCheck_WithVariations_RejectsPassword(t *testing.T) {
+	check := ForbidVariationsCheck("password")
+
+	for _, password := range []string{
+		"password",
+		"P@ssw0rd",
+		"PASSWORD",
+		"p4$$w0rd",
+		"P@ssw0rd2024!",
+		"Password1",
+		"passw0rd!!",
+	} {
+		if ok, _ := check(password); ok {
+			t.Error("Password was expected to be rejected as a variation:", password)
+		}
+	}
+}
+
+func Test_ForbidVariationsCheck_WithOtherPasswords_AcceptsPassword(t *testing.T) {
+	check := ForbidVariationsCheck("MySiteName")
+
+	for _, password := range []string{
+		"MySiteNames2024!",
+		"1MySiteName",
+		"MySiteNam",
+		"MyOtherName2024!",
+		"Just4Now!2019",
+	} {
+		if ok, errMsg := check(password); !ok {
+			t.Error("Password was expected to be accepted:", password, errMsg)
+		}
+	}
+
+	if ok, _ := check("mys1t3n@m3_2024"); ok {
+		t.Error("Password was expected to be rejected as a variation of the site name.")
+	}
+}
+
+func Test_MixedScriptCheck_WithHomoglyphs_RejectsPassword(t *testing.T) {
+	check := MixedScriptCheck()
+
+	// The first "а" is the Cyrillic homoglyph of the Latin "a"
+	if ok, _ := check("Pаssword1!"); ok {
+		t.Error("Password mixing Latin and Cyrillic letters was expected to be rejected.")
+	}
+	for _, password := range []string{"Password1!", "пароль1!", "Passwort-äöü", "12345678"} {
+		if ok, errMsg := check(password); !ok {
+			t.Error("Password was expected to be accepted:", password, errMsg)
+		}
+	}
+
+	// Only the configured scripts are restricted
+	if ok, _ := MixedScriptCheck(unicode.Latin, unicode.Greek)("Pаssword1!"); !ok {
+		t.Error("Password was expected to be accepted when Cyrillic is not checked.")
+	}
+}
+
+func Test_AllowedScriptsCheck_WithOtherScript_RejectsPassword(t *testing.T) {
+	check := AllowedScriptsCheck(unicode.Latin)
+
+	// Cyrillic homoglyphs of "pacc" only
+	if ok, _ := check("расс-2024"); ok {
+		t.Error("Password with Cyrillic letters only was expected to be rejected.")
+	}
+	if ok, errMsg := check("Passwort-äöü-2024!"); !ok {
+		t.Error("Password with Latin letters was expected to be accepted:", errMsg)
+	}
+}
@@ -0,0 +1,130 @@
+package pwd
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ------------------
+// Strength scoring
+// ------------------
+
+// Lowest and highest password strength score.
+const (
+	minScore = 0
+	maxScore = 4
+)
+
+// Password lengths which each add a point to the strength score.
+var scoreLengths = []int{8, 12, 16, 20}
+
+// hasRepeats reports whether a password repeats the same character three times in a row.
+func hasRepeats(runes []rune) bool {
+	for i := 2; i < len(runes); i++ {
+		if runes[i] == runes[i-1] && runes[i] == runes[i-2] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSequence reports whether a password contains three consecutive
+// ascending or descending characters (e.g. "abc", "321").
+func hasSequence(runes []rune) bool {
+	for i := 2; i < len(runes); i++ {
+		d1, d2 := runes[i-1]-runes[i-2], runes[i]-runes[i-1]
+		if d1 == d2 && (d1 == 1 || d1 == -1) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether a password contains any of the words, ignoring case.
+// Words shorter than three characters are ignored.
+func containsWord(password string, words []string) bool {
+	lower := strings.ToLower(password)
+	for _, w := range words {
+		if utf8.RuneCountInString(w) >= 3 && strings.Contains(lower, strings.ToLower(w)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Score rates the strength of a password from 0 (trivial) to 4 (strong),
+// e.g. for a strength meter, together with feedback on how to improve it.
+// The score is derived from the length, the character classes and detected
+// patterns such as repeated characters and sequences.
+func Score(password string) (score int, feedback []string) {
+	return ScoreWithWords(password, nil)
+}
+
+// ScoreWithWords rates the strength of a password like Score and additionally
+// lowers the score if the password contains any of the given common words
+// (e.g. a list of common passwords or the name of the site).
+func ScoreWithWords(password string, words []string) (score int, feedback []string) {
+	runes := []rune(password)
+
+	// 1. Every length milestone adds a point
+	for _, l := range scoreLengths {
+		if len(runes) >= l {
+			score++
+		}
+	}
+	if len(runes) < scoreLengths[1] {
+		feedback = append(feedback, "Use a longer password or a passphrase")
+	}
+
+	// 2. Character diversity adds or removes a point
+	classes := 0
+	distinct := map[rune]bool{}
+	for _, match := range []matchFunc{unicode.IsUpper, unicode.IsLower, unicode.IsDigit, isSpecialChar} {
+		for _, r := range runes {
+			if match(r) {
+				classes++
+				break
+			}
+		}
+	}
+	for _, r := range runes {
+		distinct[r] = true
+	}
+	switch {
+	case classes >= 3:
+		score++
+	case classes <= 1:
+		score--
+	}
+	if classes < 3 {
+		feedback = append(feedback, "Mix uppercase and lowercase letters, digits and special characters")
+	}
+	if len(distinct)*3 < len(runes) {
+		score--
+		feedback = append(feedback, "Use more different characters")
+	}
+
+	// 3. Predictable patterns remove a point each
+	if hasRepeats(runes) {
+		score--
+		feedback = append(feedback, "Avoid repeated characters")
+	}
+	if hasSequence(runes) {
+		score--
+		feedback = append(feedback, "Avoid sequences like abc or 123")
+	}
+	if containsWord(password, words) {
+		score -= 2
+		feedback = append(feedback, "Avoid common words and passwords")
+	}
+
+	// 4. A password below the minimum length is always trivial
+	if len(runes) < scoreLengths[0] || score < minScore {
+		score = minScore
+	}
+	if score > maxScore {
+		score = maxScore
+	}
+	return score, feedback
+}
@@ -0,0 +1,43 @@
+package pwd
+
+import "testing"
+
+func Test_Score_WithTrivialPasswords_ReturnsZero(t *testing.T) {
+	for _, password := range []string{"", "abc", "password", "12345678", "aaaaaaaaaaaa"} {
+		score, feedback := Score(password)
+
+		areEqual(t, 0, score)
+		if len(feedback) == 0 {
+			t.Error("Feedback was expected for a trivial password:", password)
+		}
+	}
+}
+
+func Test_Score_WithLongDiversePassphrase_ReturnsFour(t *testing.T) {
+	score, feedback := Score("Correct-Horse-Battery-Staple-42")
+
+	areEqual(t, 4, score)
+	areEqual(t, 0, len(feedback))
+}
+
+func Test_Score_WithIncreasingStrength_ReturnsIncreasingScores(t *testing.T) {
+	weak, _ := Score("Just4Now!")
+	medium, _ := Score("correct horse battery staple")
+	strong, _ := Score("Correct-Horse-Battery-Staple-42")
+
+	if !(weak < medium && medium < strong) {
+		t.Error("Scores were expected to increase. Actual:", weak, medium, strong)
+	}
+}
+
+func Test_ScoreWithWords_WithCommonWord_LowersScore(t *testing.T) {
+	password := "Correct-Horse-Battery-Staple-42"
+
+	withoutWords, _ := Score(password)
+	withWords, feedback := ScoreWithWords(password, []string{"HORSE"})
+
+	if withWords >= withoutWords {
+		t.Error("Score was expected to be lower with a common word. Actual:", withWords, "Without words:", withoutWords)
+	}
+	areEqual(t, "Avoid common words and passwords", feedback[len(feedback)-1])
+}
@@ -2,15 +2,53 @@ package rng
 
 import (
 	"crypto/rand"
+	"encoding/base32"
 	"fmt"
+	"io"
+	"runtime"
 )
 
+// Reader is the source of randomness used by this package.
+// It defaults to crypto/rand.Reader and should only be replaced in tests.
+var Reader io.Reader = rand.Reader
+
+// FillBytes overwrites the whole slice with random bytes.
+// It allows to reuse a buffer instead of allocating a new one for every call.
+func FillBytes(b []byte) error {
+	if _, err := io.ReadFull(Reader, b); err != nil {
+		return fmt.Errorf("failed to generate %d random bytes: %w", len(b), err)
+	}
+	return nil
+}
+
 // GenerateBytes generates a random byte array with the given length.
+// A length of zero returns an empty slice. It panics for a negative length,
+// which is always a programming error when sizing a salt, key or IV.
 func GenerateBytes(length int) []byte {
+	if length < 0 {
+		panic(fmt.Sprintf("length cannot be negative: %d", length))
+	}
 	b := make([]byte, length)
-	_, err := rand.Read(b)
-	if err != nil {
-		panic(fmt.Errorf("failed to generate %d random bytes: %w", length, err))
+	if err := FillBytes(b); err != nil {
+		panic(err)
 	}
 	return b
 }
+
+// GenerateBase32Secret generates random bytes of the given length and encodes them
+// as unpadded base32, which authenticator apps expect for TOTP secrets.
+func GenerateBase32Secret(bytes int) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(GenerateBytes(bytes))
+}
+
+// Zero overwrites a byte slice with zeros, e.g. to scrub keys or passwords
+// from memory once they are no longer needed. This is a best effort: the slice is
+// kept alive until all writes have happened, which makes it unlikely that the
+// compiler discards them as dead stores, but Go gives no guarantee for it.
+// Copies of the data elsewhere in memory are not cleared.
+func Zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
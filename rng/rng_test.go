@@ -2,6 +2,8 @@ package rng
 
 import (
 	"bytes"
+	"encoding/base32"
+	"strings"
 	"testing"
 )
 
@@ -36,3 +38,112 @@ func Test_GenerateBytes_WithSameLength_ReturnsDifferentArrays(t *testing.T) {
 		t.Error("Randomly generated bytes were expected to differ.")
 	}
 }
+
+func Test_GenerateBytes_WithFixedReader_ReturnsDeterministicBytes(t *testing.T) {
+	original := Reader
+	defer func() { Reader = original }()
+
+	expected := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	Reader = bytes.NewReader(expected)
+
+	actual := GenerateBytes(len(expected))
+
+	if !bytes.Equal(expected, actual) {
+		t.Error("Expected:", expected, "Actual:", actual)
+	}
+}
+
+func Test_GenerateBytes_WithShortRead_Panics(t *testing.T) {
+	original := Reader
+	defer func() { Reader = original }()
+
+	Reader = bytes.NewReader([]byte{1, 2, 3})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("GenerateBytes was expected to panic on a short read.")
+		}
+	}()
+	GenerateBytes(10)
+}
+
+func Test_GenerateBytes_WithNegativeLength_PanicsWithClearMessage(t *testing.T) {
+	defer func() {
+		r := recover()
+		msg, ok := r.(string)
+		if !ok || !strings.Contains(msg, "length cannot be negative") {
+			t.Error("GenerateBytes was expected to panic with a clear message. Actual:", r)
+		}
+	}()
+	GenerateBytes(-1)
+}
+
+func Test_FillBytes_WithFixedReader_OverwritesWholeBuffer(t *testing.T) {
+	original := Reader
+	defer func() { Reader = original }()
+
+	expected := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	Reader = bytes.NewReader(expected)
+	buffer := bytes.Repeat([]byte{255}, len(expected))
+
+	err := FillBytes(buffer)
+
+	if err != nil {
+		t.Error("FillBytes returned an unexpected error: " + err.Error())
+	}
+	if !bytes.Equal(expected, buffer) {
+		t.Error("Expected:", expected, "Actual:", buffer)
+	}
+}
+
+func Test_FillBytes_WithShortRead_ReturnsError(t *testing.T) {
+	original := Reader
+	defer func() { Reader = original }()
+
+	Reader = bytes.NewReader([]byte{1, 2, 3})
+
+	if err := FillBytes(make([]byte, 10)); err == nil {
+		t.Error("FillBytes was expected to return an error on a short read.")
+	}
+}
+
+func Test_GenerateBase32Secret_ReturnsUnpaddedBase32OfGivenLength(t *testing.T) {
+	for _, length := range []int{10, 20, 32} {
+		secret := GenerateBase32Secret(length)
+
+		key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+		if err != nil {
+			t.Error("Secret was expected to be valid base32:", secret, err.Error())
+		}
+		if len(key) != length {
+			t.Error("Expected length:", length, "Actual length:", len(key))
+		}
+	}
+}
+
+func BenchmarkGenerateBytes(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GenerateBytes(16)
+	}
+}
+
+func BenchmarkFillBytes(b *testing.B) {
+	buffer := make([]byte, 16)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := FillBytes(buffer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Test_Zero_OverwritesAllBytes(t *testing.T) {
+	b := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	Zero(b)
+
+	if !bytes.Equal(make([]byte, 10), b) {
+		t.Error("Expected:", make([]byte, 10), "Actual:", b)
+	}
+}
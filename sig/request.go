@@ -0,0 +1,103 @@
+package sig
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HeaderEncoding is the encoding of a signature in an HTTP header.
+type HeaderEncoding int
+
+const (
+	// HexEncoding decodes hexadecimal signatures (case-insensitive).
+	HexEncoding HeaderEncoding = iota
+
+	// Base64Encoding decodes standard base64 signatures with padding.
+	Base64Encoding
+)
+
+// decode decodes a signature from a header value.
+func (e HeaderEncoding) decode(value string) ([]byte, error) {
+	switch e {
+	case HexEncoding:
+		return hex.DecodeString(value)
+	case Base64Encoding:
+		return base64.StdEncoding.DecodeString(value)
+	default:
+		return nil, fmt.Errorf("unsupported header encoding %d", e)
+	}
+}
+
+// DefaultMaxBodyBytes is the maximum body size which VerifyRequest reads.
+const DefaultMaxBodyBytes = 1 << 20
+
+// ErrBodyTooLarge is returned when a request body exceeds the maximum body size.
+var ErrBodyTooLarge = errors.New("request body is too large")
+
+// VerifyRequest verifies the signature of a webhook request, which is read from the
+// named header in the given encoding, against the HMAC of the request body.
+// The body is restored, so that handlers further down the chain can still read it.
+// An error is returned if the header is missing or malformed or the body cannot be
+// read, whereas a wrong signature only returns false.
+// Bodies of more than DefaultMaxBodyBytes are rejected with ErrBodyTooLarge before
+// the signature is checked. Use VerifyRequestWithLimit for another maximum.
+func VerifyRequest(
+	hasher HashFactory,
+	key []byte,
+	r *http.Request,
+	headerName string,
+	encoding HeaderEncoding) (bool, error) {
+	return VerifyRequestWithLimit(hasher, key, r, headerName, encoding, DefaultMaxBodyBytes)
+}
+
+// VerifyRequestWithLimit verifies the signature of a webhook request like VerifyRequest,
+// but reads at most maxBodyBytes of the body and returns ErrBodyTooLarge for longer
+// bodies, so that an unauthenticated client cannot make the server buffer any amount
+// of data before the signature is checked.
+func VerifyRequestWithLimit(
+	hasher HashFactory,
+	key []byte,
+	r *http.Request,
+	headerName string,
+	encoding HeaderEncoding,
+	maxBodyBytes int64) (bool, error) {
+	if r == nil {
+		panic("r cannot be nil.")
+	}
+	if maxBodyBytes < 0 {
+		panic(fmt.Sprintf("maxBodyBytes cannot be negative: %d", maxBodyBytes))
+	}
+
+	// 1. Decode the signature from the header
+	value := r.Header.Get(headerName)
+	if value == "" {
+		return false, fmt.Errorf("missing signature header %q", headerName)
+	}
+	signature, err := encoding.decode(value)
+	if err != nil {
+		return false, fmt.Errorf("malformed signature header %q: %w", headerName, err)
+	}
+
+	// 2. Read the body and restore it for the next handler
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		// Read one byte more than the maximum to detect longer bodies
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+		closeErr := r.Body.Close()
+		if err = errors.Join(err, closeErr); err != nil {
+			return false, fmt.Errorf("error reading request body: %w", err)
+		}
+		if int64(len(body)) > maxBodyBytes {
+			return false, fmt.Errorf("%w: maximum is %d bytes", ErrBodyTooLarge, maxBodyBytes)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// 3. Compare the signature in constant time
+	return Validate(hasher, key, body, signature), nil
+}
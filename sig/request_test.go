@@ -0,0 +1,107 @@
+package sig
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_VerifyRequest_WithValidSignature_ReturnsTrueAndRestoresBody(t *testing.T) {
+	key := []byte("some-stupid-webhook-secret")
+	body := `{"event":"payment.succeeded"}`
+	signature := ComputeSHA256(key, []byte(body))
+
+	for encoding, header := range map[HeaderEncoding]string{
+		HexEncoding:    hex.EncodeToString(signature),
+		Base64Encoding: base64.StdEncoding.EncodeToString(signature),
+	} {
+		r := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+		r.Header.Set("X-Signature", header)
+
+		ok, err := VerifyRequest(sha256.New, key, r, "X-Signature", encoding)
+		if err != nil {
+			t.Error("VerifyRequest returned an unexpected error: " + err.Error())
+		}
+		if !ok {
+			t.Error("VerifyRequest was expected to accept a valid signature:", header)
+		}
+
+		restored, _ := io.ReadAll(r.Body)
+		if string(restored) != body {
+			t.Error("Expected:", body, "Actual:", string(restored))
+		}
+	}
+}
+
+func Test_VerifyRequest_WithInvalidSignature_ReturnsFalse(t *testing.T) {
+	key := []byte("some-stupid-webhook-secret")
+	body := `{"event":"payment.succeeded"}`
+	signature := ComputeSHA256([]byte("another-secret"), []byte(body))
+
+	r := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+	r.Header.Set("X-Signature", hex.EncodeToString(signature))
+
+	ok, err := VerifyRequest(sha256.New, key, r, "X-Signature", HexEncoding)
+	if err != nil {
+		t.Error("VerifyRequest returned an unexpected error: " + err.Error())
+	}
+	if ok {
+		t.Error("VerifyRequest was expected to reject an invalid signature.")
+	}
+}
+
+func Test_VerifyRequest_WithMissingOrMalformedHeader_ReturnsError(t *testing.T) {
+	key := []byte("some-stupid-webhook-secret")
+
+	for _, header := range []string{"", "not-hex"} {
+		r := httptest.NewRequest("POST", "/webhook", strings.NewReader("{}"))
+		if header != "" {
+			r.Header.Set("X-Signature", header)
+		}
+		if _, err := VerifyRequest(sha256.New, key, r, "X-Signature", HexEncoding); err == nil {
+			t.Error("VerifyRequest was expected to return an error for header:", header)
+		}
+	}
+}
+
+func Test_VerifyRequestWithLimit_WithBodyAboveLimit_ReturnsErrBodyTooLarge(t *testing.T) {
+	key := []byte("some-stupid-webhook-secret")
+	body := `{"event":"payment.succeeded"}`
+	signature := hex.EncodeToString(ComputeSHA256(key, []byte(body)))
+
+	// A body of exactly the maximum size is accepted
+	r := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+	r.Header.Set("X-Signature", signature)
+	ok, err := VerifyRequestWithLimit(sha256.New, key, r, "X-Signature", HexEncoding, int64(len(body)))
+	if err != nil || !ok {
+		t.Error("VerifyRequestWithLimit was expected to accept a body of the maximum size:", err)
+	}
+
+	// A body of one byte more is rejected
+	r = httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+	r.Header.Set("X-Signature", signature)
+	ok, err = VerifyRequestWithLimit(sha256.New, key, r, "X-Signature", HexEncoding, int64(len(body)-1))
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Error("Expected:", ErrBodyTooLarge, "Actual:", err)
+	}
+	if ok {
+		t.Error("VerifyRequestWithLimit was expected to reject a body above the maximum size.")
+	}
+}
+
+func Test_VerifyRequest_WithBodyAboveDefaultLimit_ReturnsErrBodyTooLarge(t *testing.T) {
+	key := []byte("some-stupid-webhook-secret")
+	body := strings.Repeat("a", DefaultMaxBodyBytes+1)
+
+	r := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+	r.Header.Set("X-Signature", hex.EncodeToString(ComputeSHA256(key, []byte(body))))
+
+	if _, err := VerifyRequest(sha256.New, key, r, "X-Signature", HexEncoding); !errors.Is(err, ErrBodyTooLarge) {
+		t.Error("Expected:", ErrBodyTooLarge, "Actual:", err)
+	}
+}
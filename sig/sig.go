@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"hash"
+	"io"
 
 	"github.com/dusted-go/security/compare"
 )
@@ -30,6 +31,42 @@ func Validate(hasher HashFactory, key, msg, signature []byte) bool {
 
 }
 
+// ValidateAny verifies an existing signature against multiple keys, e.g. during key rotation.
+// All keys are always evaluated so that the time taken doesn't reveal which key matched.
+func ValidateAny(hasher HashFactory, keys [][]byte, msg, signature []byte) bool {
+	ok := false
+	for _, key := range keys {
+		ok = Validate(hasher, key, msg, signature) || ok
+	}
+	return ok
+}
+
+// ComputeMulti calculates one signature per hashing function for a given key and
+// message, e.g. to send both an HMAC-SHA256 and an HMAC-SHA512 signature while
+// migrating from one algorithm to the other.
+func ComputeMulti(key []byte, msg []byte, hashers ...HashFactory) [][]byte {
+	signatures := make([][]byte, len(hashers))
+	for i, hasher := range hashers {
+		signatures[i] = Compute(hasher, key, msg)
+	}
+	return signatures
+}
+
+// ValidateMulti verifies pairs of hashing functions and signatures, where the
+// signature at an index belongs to the hashing function at the same index.
+// It accepts the message if any pair verifies and rejects it if the number of
+// hashing functions and signatures differs. All pairs are always evaluated.
+func ValidateMulti(key []byte, msg []byte, hashers []HashFactory, signatures [][]byte) bool {
+	if len(hashers) != len(signatures) {
+		return false
+	}
+	ok := false
+	for i, hasher := range hashers {
+		ok = Validate(hasher, key, msg, signatures[i]) || ok
+	}
+	return ok
+}
+
 // ComputeSHA256 calculates a signature for a given key and message.
 func ComputeSHA256(key, msg []byte) []byte {
 	return Compute(sha256.New, key, msg)
@@ -39,3 +76,57 @@ func ComputeSHA256(key, msg []byte) []byte {
 func ValidateSHA256(key, msg, signature []byte) bool {
 	return Validate(sha256.New, key, msg, signature)
 }
+
+// SigWriter computes a signature over all data written to it.
+// It allows to sign large messages (e.g. request bodies) without buffering them.
+type SigWriter struct { // nolint: revive
+	mac hash.Hash
+}
+
+// NewWriter creates a new SigWriter for a given hashing function and key.
+func NewWriter(hasher HashFactory, key []byte) *SigWriter {
+	return &SigWriter{mac: hmac.New(hasher, key)}
+}
+
+// Write adds more data to the signed message. It never returns an error.
+func (w *SigWriter) Write(p []byte) (n int, err error) {
+	return w.mac.Write(p)
+}
+
+// Sum returns the signature of all data written so far.
+func (w *SigWriter) Sum() []byte {
+	return w.mac.Sum(nil)
+}
+
+// VerifyReader reads a message to completion and verifies an existing signature
+// against a given hashing function and key without buffering the message.
+// An error is returned if the message cannot be read.
+func VerifyReader(hasher HashFactory, key []byte, r io.Reader, signature []byte) (bool, error) {
+	w := NewWriter(hasher, key)
+	if _, err := io.Copy(w, r); err != nil {
+		return false, fmt.Errorf("error reading message: %w", err)
+	}
+	return compare.Hashes(signature, w.Sum()), nil
+}
+
+// Verifier validates signatures for a fixed hashing function and key.
+// It reuses the keyed HMAC state between calls and is therefore faster than
+// Validate on hot paths. A Verifier is not safe for concurrent use.
+type Verifier struct {
+	mac hash.Hash
+}
+
+// NewVerifier creates a new Verifier for a given hashing function and key.
+func NewVerifier(hasher HashFactory, key []byte) *Verifier {
+	return &Verifier{mac: hmac.New(hasher, key)}
+}
+
+// Verify verifies an existing signature against a given message.
+func (v *Verifier) Verify(msg, signature []byte) bool {
+	v.mac.Reset()
+	_, err := v.mac.Write(msg)
+	if err != nil {
+		panic(fmt.Errorf("error generating HMAC hash: %w", err))
+	}
+	return compare.Hashes(signature, v.mac.Sum(nil))
+}
@@ -2,7 +2,12 @@ package sig
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"io"
 	"testing"
+	"testing/iotest"
 )
 
 func Test_HmacSha256_WithEmptyMessageAndEmptyKey_ReturnsCorrectHash(t *testing.T) {
@@ -52,3 +57,152 @@ func Test_HmacSha256_WithRandomMessageAndRandomKey_ReturnsCorrectHash(t *testing
 		t.Error("Expected:", expected, "Actual:", actual)
 	}
 }
+
+func Test_Verifier_WithMultipleMessages_MatchesValidate(t *testing.T) {
+	key := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	msgs := [][]byte{
+		{250, 240, 230, 100, 80, 1, 50, 40, 140},
+		{},
+		{1, 2, 3},
+	}
+
+	verifier := NewVerifier(sha256.New, key)
+
+	for _, msg := range msgs {
+		signature := ComputeSHA256(key, msg)
+		if !verifier.Verify(msg, signature) {
+			t.Error("Verifier was expected to accept a valid signature for:", msg)
+		}
+		if verifier.Verify(append(msg, 0), signature) {
+			t.Error("Verifier was expected to reject a signature for a different message.")
+		}
+	}
+}
+
+func Test_ValidateAny_WithSecondKeyCorrect_ReturnsTrue(t *testing.T) {
+	oldKey := []byte{9, 8, 7, 6, 5, 4, 3, 2, 1}
+	newKey := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	msg := []byte{250, 240, 230, 100, 80, 1, 50, 40, 140}
+	signature := ComputeSHA256(newKey, msg)
+
+	if !ValidateAny(sha256.New, [][]byte{oldKey, newKey}, msg, signature) {
+		t.Error("ValidateAny was expected to accept a signature from the second key.")
+	}
+	if ValidateAny(sha256.New, [][]byte{oldKey}, msg, signature) {
+		t.Error("ValidateAny was expected to reject a signature from an unknown key.")
+	}
+}
+
+func Test_ComputeMultiAndValidateMulti_WithSHA256AndSHA512(t *testing.T) {
+	key := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	msg := []byte{250, 240, 230, 100, 80, 1, 50, 40, 140}
+
+	signatures := ComputeMulti(key, msg, sha256.New, sha512.New)
+
+	if len(signatures) != 2 {
+		t.Fatal("Expected:", 2, "Actual:", len(signatures))
+	}
+	if !ValidateSHA256(key, msg, signatures[0]) {
+		t.Error("First signature was expected to be an HMAC-SHA256 signature.")
+	}
+	if !Validate(sha512.New, key, msg, signatures[1]) {
+		t.Error("Second signature was expected to be an HMAC-SHA512 signature.")
+	}
+
+	// Each algorithm verifies independently, e.g. a receiver which only knows one of them
+	if !ValidateMulti(key, msg, []HashFactory{sha256.New}, signatures[:1]) {
+		t.Error("ValidateMulti was expected to accept the SHA-256 signature on its own.")
+	}
+	if !ValidateMulti(key, msg, []HashFactory{sha512.New}, signatures[1:]) {
+		t.Error("ValidateMulti was expected to accept the SHA-512 signature on its own.")
+	}
+	if !ValidateMulti(key, msg, []HashFactory{sha256.New, sha512.New}, [][]byte{{0}, signatures[1]}) {
+		t.Error("ValidateMulti was expected to accept if any pair verifies.")
+	}
+	if ValidateMulti(key, msg, []HashFactory{sha512.New}, signatures[:1]) {
+		t.Error("ValidateMulti was expected to reject a signature paired with the wrong algorithm.")
+	}
+	if ValidateMulti(key, msg, []HashFactory{sha256.New, sha512.New}, signatures[:1]) {
+		t.Error("ValidateMulti was expected to reject a different number of algorithms and signatures.")
+	}
+}
+
+func Test_SigWriter_WithChunks_MatchesComputeSHA256(t *testing.T) {
+	key := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	chunks := [][]byte{
+		{250, 240, 230},
+		{100, 80, 1},
+		{},
+		{50, 40, 140},
+	}
+	expected := ComputeSHA256(key, bytes.Join(chunks, nil))
+
+	writer := NewWriter(sha256.New, key)
+	for _, chunk := range chunks {
+		if _, err := writer.Write(chunk); err != nil {
+			t.Error("Unexpected error when writing chunk:", err.Error())
+		}
+	}
+	actual := writer.Sum()
+
+	if !bytes.Equal(expected, actual) {
+		t.Error("Expected:", expected, "Actual:", actual)
+	}
+}
+
+func Test_VerifyReader_WithMultiChunkReader_ReturnsTrue(t *testing.T) {
+	key := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	msg := bytes.Repeat([]byte{250, 240, 230, 100, 80, 1, 50, 40, 140}, 1000)
+	signature := ComputeSHA256(key, msg)
+
+	ok, err := VerifyReader(sha256.New, key, iotest.HalfReader(bytes.NewReader(msg)), signature)
+
+	if err != nil {
+		t.Error("VerifyReader returned an unexpected error: " + err.Error())
+	}
+	if !ok {
+		t.Error("VerifyReader was expected to accept a valid signature.")
+	}
+
+	ok, _ = VerifyReader(sha256.New, key, bytes.NewReader(msg[1:]), signature)
+	if ok {
+		t.Error("VerifyReader was expected to reject a signature for a different message.")
+	}
+}
+
+func Test_VerifyReader_WithFailingReader_ReturnsError(t *testing.T) {
+	key := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	readErr := errors.New("connection reset")
+
+	ok, err := VerifyReader(sha256.New, key, io.MultiReader(bytes.NewReader(key), iotest.ErrReader(readErr)), nil)
+
+	if ok {
+		t.Error("VerifyReader was not expected to accept a signature when reading fails.")
+	}
+	if !errors.Is(err, readErr) {
+		t.Error("Expected:", readErr, "Actual:", err)
+	}
+}
+
+func BenchmarkValidate(b *testing.B) {
+	key := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	msg := []byte{250, 240, 230, 100, 80, 1, 50, 40, 140}
+	signature := ComputeSHA256(key, msg)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Validate(sha256.New, key, msg, signature)
+	}
+}
+
+func BenchmarkVerifier(b *testing.B) {
+	key := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	msg := []byte{250, 240, 230, 100, 80, 1, 50, 40, 140}
+	signature := ComputeSHA256(key, msg)
+	verifier := NewVerifier(sha256.New, key)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		verifier.Verify(msg, signature)
+	}
+}
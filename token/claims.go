@@ -0,0 +1,43 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Claims is a structured token payload for the common case which would
+// otherwise require every caller to invent their own encoding of the data.
+type Claims struct {
+	Subject  string         `json:"sub,omitempty"`
+	IssuedAt time.Time      `json:"iat"`
+	Custom   map[string]any `json:"ext,omitempty"`
+}
+
+// GenerateClaims JSON encodes the claims and generates an encrypted token with them as data.
+// IssuedAt is set to the current time of the Generator if it is zero.
+func (g *Generator) GenerateClaims(kind string, c Claims, ttl time.Duration) (string, error) {
+	if c.IssuedAt.IsZero() {
+		c.IssuedAt = g.now().UTC()
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("could not encode token claims: %w", err)
+	}
+	return g.Generate(kind, data, ttl)
+}
+
+// ValidateClaims validates a token from GenerateClaims and decodes its claims.
+// Custom claims are decoded like encoding/json decodes into an any value,
+// e.g. numbers become float64.
+func (v *Validator) ValidateClaims(kind string, token string) (Claims, error) {
+	data, _, err := v.Validate(kind, token)
+	if err != nil {
+		return Claims{}, err
+	}
+	var c Claims
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Claims{}, fmt.Errorf("could not decode token claims: %w", err)
+	}
+	return c, nil
+}
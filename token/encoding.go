@@ -0,0 +1,44 @@
+package token
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Characters of a base62 encoded token.
+// big.Int would otherwise also accept a leading sign when parsing.
+// Tokens are encoded as arbitrarily large numbers with math/big rather than with
+// github.com/dusted-go/encoding/base62, which only encodes an int and orders
+// uppercase before lowercase letters, so that it would neither fit a token nor
+// decode tokens which have already been issued.
+const base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Maximum length of a base62 encoded token. Decoding a base62 number takes time
+// quadratic in its length, therefore longer input is rejected before it is parsed.
+// It is far beyond the length of any token which fits into a URL or cookie.
+const maxBase62Length = 8192
+
+// encodeBase62 encodes a token into an alphanumeric string.
+// Tokens never start with a zero byte, therefore no leading zeros are lost.
+func encodeBase62(token string) string {
+	n := new(big.Int).SetBytes([]byte(token))
+	return n.Text(62)
+}
+
+// decodeBase62 reverts encodeBase62.
+func decodeBase62(encoded string) (string, error) {
+	if len(encoded) > maxBase62Length {
+		return "", errors.New("base62 encoded token is too long")
+	}
+	for _, r := range encoded {
+		if !strings.ContainsRune(base62Alphabet, r) {
+			return "", errors.New("token must be base62 encoded")
+		}
+	}
+	n, ok := new(big.Int).SetString(encoded, 62)
+	if !ok {
+		return "", errors.New("token must be base62 encoded")
+	}
+	return string(n.Bytes()), nil
+}
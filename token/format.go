@@ -0,0 +1,128 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatVersion identifies the format of an encrypted token.
+type FormatVersion int
+
+const (
+	// FormatLegacy is the original "signature.cipher" format without a version prefix.
+	FormatLegacy FormatVersion = 0
+
+	// FormatV1 prefixes the token with "v1." and includes the prefix in the signature,
+	// so that a token cannot be downgraded to another format.
+	//
+	// The exact layout, which other implementations (e.g. the .NET reference
+	// implementation in testdata/Token.cs) must follow, is:
+	//
+	//	message   = kind "." base64url(data) "." expiry
+	//	cipher    = iv || AES-CBC(encryptionKey, iv, PKCS7(message))
+	//	signature = HMAC-SHA256(signingKey, "v1." || cipher)
+	//	token     = "v1." base64url(signature) "." base64url(cipher)
+	//
+	// base64url is the URL-safe alphabet of RFC 4648 without padding, the IV is
+	// one random AES block (16 bytes) and the expiry is formatted as RFC 3339
+	// in UTC without fractional seconds (e.g. "2023-05-01T13:00:00Z").
+	// The kind must not contain a dot.
+	FormatV1 FormatVersion = 1
+
+	// FormatV2 extends FormatV1 with an identifier of the signature algorithm,
+	// "v2.HS256.<signature>.<cipher>", and includes it in the signature, so that
+	// the MAC binds the choice of algorithm and a token cannot be validated with
+	// another algorithm than the one it was signed with.
+	//
+	// The message and cipher are the same as in FormatV1, the layout differs in
+	// the prefix only:
+	//
+	//	signature = HMAC-SHA256(signingKey, "v2.HS256." || cipher)
+	//	token     = "v2.HS256." base64url(signature) "." base64url(cipher)
+	//
+	// With WithVisibleKind the segment "k." base64url(kind) "." follows the prefix
+	// and is signed as well: HMAC-SHA256(signingKey, "v2.HS256.k." base64url(kind) "." || cipher).
+	// testdata/Token.cs implements FormatV2 without a visible kind.
+	FormatV2 FormatVersion = 2
+
+	// LatestFormat is the newest format, which Generators emit by default.
+	LatestFormat = FormatV2
+)
+
+// Identifier of the HMAC-SHA256 signature algorithm in FormatV2 tokens.
+const hmacSHA256Algorithm = "HS256"
+
+// Prefix which marks a token as signed but not encrypted.
+// Encrypted tokens start with a base64 encoded signature or a version
+// prefix, neither of which can be confused with it.
+const signedPrefix = "s."
+
+// signedMessage returns the bytes which are signed in a signed-only token.
+// The mode prefix is included so that a signature can never be moved
+// between an encrypted and a signed-only token.
+func signedMessage(plainText []byte) []byte {
+	return append([]byte(signedPrefix), plainText...)
+}
+
+// supported reports whether the format version is known to this package.
+func (f FormatVersion) supported() bool {
+	return f >= FormatLegacy && f <= LatestFormat
+}
+
+// prefix returns the version prefix which is prepended to an encrypted token.
+// From FormatV2 onwards it includes the signature algorithm.
+func (f FormatVersion) prefix() string {
+	switch f {
+	case FormatLegacy:
+		return ""
+	case FormatV1:
+		return "v1."
+	default:
+		return fmt.Sprintf("v%d.%s.", f, hmacSHA256Algorithm)
+	}
+}
+
+// parseAlgorithm splits the signature algorithm from an encrypted token without
+// its version prefix. Formats before FormatV2 are always signed with HMAC-SHA256.
+// The algorithm is part of the prefix and therefore covered by the signature.
+func (f FormatVersion) parseAlgorithm(token string) (rest string, err error) {
+	if f < FormatV2 {
+		return token, nil
+	}
+	algorithm, rest, found := strings.Cut(token, ".")
+	if !found || algorithm != hmacSHA256Algorithm {
+		return "", errors.New("unsupported signature algorithm")
+	}
+	return rest, nil
+}
+
+// signedMessage returns the bytes which are signed in an encrypted token.
+func (f FormatVersion) signedMessage(cipher []byte) []byte {
+	if f == FormatLegacy {
+		return cipher
+	}
+	return append([]byte(f.prefix()), cipher...)
+}
+
+// parseVersion splits the version prefix from an encrypted token.
+// Tokens without a version prefix are in the legacy format.
+func parseVersion(token string) (version FormatVersion, rest string, err error) {
+	// A legacy token starts with a base64 encoded signature,
+	// which is far longer than a version prefix.
+	sep := strings.Index(token, ".")
+	if sep < 2 || sep > 4 || token[0] != 'v' {
+		return FormatLegacy, token, nil
+	}
+
+	n, err := strconv.Atoi(token[1:sep])
+	if err != nil || n < 1 {
+		return FormatLegacy, token, nil
+	}
+	version = FormatVersion(n)
+	if !version.supported() {
+		return 0, "", errors.New("unsupported token format version")
+	}
+	return version, token[sep+1:], nil
+}
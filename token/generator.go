@@ -2,10 +2,12 @@ package token
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/dusted-go/security/aes"
+	"github.com/dusted-go/security/rng"
 	"github.com/dusted-go/security/sig"
 )
 
@@ -14,48 +16,216 @@ type Generator struct {
 	now           func() time.Time
 	encryptionKey []byte
 	signingKey    []byte
+	base62        bool
+	version       FormatVersion
+	visibleKind   bool
+	maxTTL        time.Duration
+	clampTTL      bool
+}
+
+// ErrTTLExceeded is returned when a token is requested with a TTL above the maximum of WithMaxTTL.
+var ErrTTLExceeded = errors.New("token TTL exceeds the maximum TTL")
+
+// GeneratorOption configures optional behaviour of a Generator.
+type GeneratorOption = func(g *Generator)
+
+// WithBase62Encoding makes the Generator encode tokens as alphanumeric base62
+// strings instead of base64url, which survives email clients and URL mangling.
+// The Validator detects base62 encoded tokens without any further configuration,
+// but rejects tokens of more than 8192 characters, which carry roughly 6 KB.
+func WithBase62Encoding() GeneratorOption {
+	return func(g *Generator) {
+		g.base62 = true
+	}
+}
+
+// WithFormatVersion makes the Generator emit encrypted tokens in the given format
+// instead of LatestFormat, e.g. to keep issuing FormatLegacy tokens until all
+// validators have been upgraded, or to opt into FormatV2.
+func WithFormatVersion(version FormatVersion) GeneratorOption {
+	if !version.supported() {
+		panic(fmt.Sprintf("unsupported token format version %d", version))
+	}
+	return func(g *Generator) {
+		g.version = version
+	}
+}
+
+// WithClock replaces the clock of the Generator which determines the expiry date
+// of new tokens, e.g. to test expiry boundaries without sleeping.
+func WithClock(now func() time.Time) GeneratorOption {
+	if now == nil {
+		panic("now cannot be nil.")
+	}
+	return func(g *Generator) {
+		g.now = now
+	}
+}
+
+// WithMaxTTL limits the TTL of the tokens which the Generator creates, so that
+// an application bug cannot issue an effectively permanent credential.
+// Generate rejects a longer TTL with ErrTTLExceeded unless WithTTLClamping is used.
+func WithMaxTTL(d time.Duration) GeneratorOption {
+	if d <= 0 {
+		panic("d must be greater than zero.")
+	}
+	return func(g *Generator) {
+		g.maxTTL = d
+	}
+}
+
+// WithTTLClamping makes the Generator shorten a TTL above the maximum of WithMaxTTL
+// to the maximum instead of rejecting it.
+func WithTTLClamping() GeneratorOption {
+	return func(g *Generator) {
+		g.clampTTL = true
+	}
 }
 
 // NewGenerator creates a new token generator.
+// It panics if the encryption key is not 16, 24 or 32 bytes long or consists of zero bytes only.
 func NewGenerator(
 	encryptionKey []byte,
-	signingKey []byte) *Generator {
+	signingKey []byte,
+	opts ...GeneratorOption) *Generator {
 	if encryptionKey == nil {
 		panic("encryptionKey cannot be nil.")
 	}
+	if err := checkEncryptionKey(encryptionKey); err != nil {
+		panic(err.Error())
+	}
 	if signingKey == nil {
 		panic("signingKey cannot be nil.")
 	}
-	return &Generator{
+	g := &Generator{
 		now:           time.Now,
 		encryptionKey: encryptionKey,
 		signingKey:    signingKey,
+		version:       LatestFormat,
 	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
 }
 
-func (g *Generator) Generate(kind string, data []byte, ttl time.Duration) (string, error) {
-	// 1. Generate expiry date
-	expiry := g.now().UTC().Add(ttl)
+// NewGeneratorErr creates a new token generator like NewGenerator,
+// but returns an error instead of panicking if a key is nil, has the wrong length
+// or consists of zero bytes only.
+// Use it when keys are loaded from configuration at runtime.
+func NewGeneratorErr(
+	encryptionKey []byte,
+	signingKey []byte,
+	opts ...GeneratorOption) (*Generator, error) {
+	if err := checkKeys(encryptionKey, signingKey); err != nil {
+		return nil, fmt.Errorf("could not create token generator: %w", err)
+	}
+	return NewGenerator(encryptionKey, signingKey, opts...), nil
+}
+
+// NewGeneratorFromMaster creates a new token generator with encryption and
+// signing keys derived from a single master secret.
+func NewGeneratorFromMaster(master []byte, opts ...GeneratorOption) *Generator {
+	encryptionKey, signingKey := deriveKeys(master)
+	return NewGenerator(encryptionKey, signingKey, opts...)
+}
+
+// encode applies the configured final encoding to a token.
+func (g *Generator) encode(token string) string {
+	if g.base62 {
+		return encodeBase62(token)
+	}
+	return token
+}
+
+// Zeroize overwrites the encryption and signing keys with zeros.
+// The Generator keeps references to the key slices which were passed to it,
+// so callers which rely on this must not hold any other copies of the keys.
+// The Generator must not be used after it has been zeroized.
+func (g *Generator) Zeroize() {
+	rng.Zero(g.encryptionKey)
+	rng.Zero(g.signingKey)
+}
+
+// limitTTL applies the maximum TTL of WithMaxTTL to the requested TTL.
+func (g *Generator) limitTTL(ttl time.Duration) (time.Duration, error) {
+	if g.maxTTL == 0 || ttl <= g.maxTTL {
+		return ttl, nil
+	}
+	if g.clampTTL {
+		return g.maxTTL, nil
+	}
+	return 0, fmt.Errorf("%w: %s is longer than %s", ErrTTLExceeded, ttl, g.maxTTL)
+}
 
-	// 2. Concatenate the token parts
+// message concatenates the token kind, data and expiry date into the plain token message.
+func (g *Generator) message(kind string, data []byte, ttl time.Duration) string {
+	expiry := g.now().UTC().Add(ttl)
 	encodedData := base64.RawURLEncoding.EncodeToString(data)
-	plainText := fmt.Sprintf("%s.%s.%s", kind, encodedData, expiry.Format(time.RFC3339))
+	return fmt.Sprintf("%s.%s.%s", kind, encodedData, expiry.Format(time.RFC3339))
+}
+
+// Generate creates a signed and encrypted token of the given kind which expires after ttl.
+// The kind, data and expiry date are only part of the encrypted message and never
+// appear in the token in any form. Use WithVisibleKind or GenerateSigned to opt into
+// readable kinds or tokens.
+// The IV is read from rng.Reader, therefore a fixed reader together with WithClock
+// produces deterministic tokens, e.g. for golden-file tests of the token format.
+func (g *Generator) Generate(kind string, data []byte, ttl time.Duration) (string, error) {
+	// 1. Generate expiry date and concatenate the token parts
+	ttl, err := g.limitTTL(ttl)
+	if err != nil {
+		return "", fmt.Errorf("could not generate token: %w", err)
+	}
+	plainText := g.message(kind, data, ttl)
 
-	// 3. Encrypt the data
+	// 2. Encrypt the data
 	cipher, err := aes.Encrypt(g.encryptionKey, []byte(plainText))
 	if err != nil {
 		return "", fmt.Errorf("could not generate token: %w", err)
 	}
 
-	// 4. Compute a signature
-	signature := sig.ComputeSHA256(g.signingKey, cipher)
+	// 3. Compute a signature over the version prefix, the optional visible kind and the cipher
+	kindHeader := ""
+	if g.visibleKind {
+		kindHeader = visibleKindHeader(kind)
+	}
+	signature := sig.ComputeSHA256(g.signingKey, g.version.signedMessage(append([]byte(kindHeader), cipher...)))
 
-	// 5. Concatenate signature and data into token
+	// 4. Concatenate version prefix, visible kind, signature and data into token
 	token := fmt.Sprintf(
-		"%s.%s",
+		"%s%s%s.%s",
+		g.version.prefix(),
+		kindHeader,
 		base64.RawURLEncoding.EncodeToString(signature),
 		base64.RawURLEncoding.EncodeToString(cipher))
 
-	return token, nil
+	return g.encode(token), nil
+
+}
+
+// GenerateSigned creates a token which is signed but NOT encrypted.
+// Anyone who holds the token can read its kind, data and expiry date,
+// and anyone with the signing key can verify it (see NewSignatureValidator).
+// Only use it for data which is not confidential.
+// A TTL above the maximum of WithMaxTTL is clamped or rejected like in Generate.
+func (g *Generator) GenerateSigned(kind string, data []byte, ttl time.Duration) (string, error) {
+	// 1. Generate expiry date and concatenate the token parts
+	ttl, err := g.limitTTL(ttl)
+	if err != nil {
+		return "", fmt.Errorf("could not generate token: %w", err)
+	}
+	plainText := []byte(g.message(kind, data, ttl))
+
+	// 2. Compute a signature over the mode prefix and the plain message
+	signature := sig.ComputeSHA256(g.signingKey, signedMessage(plainText))
+
+	// 3. Concatenate mode prefix, signature and data into token
+	token := fmt.Sprintf(
+		"%s%s.%s",
+		signedPrefix,
+		base64.RawURLEncoding.EncodeToString(signature),
+		base64.RawURLEncoding.EncodeToString(plainText))
 
+	return g.encode(token), nil
 }
@@ -0,0 +1,57 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dusted-go/security/aes"
+	"github.com/dusted-go/security/kdf"
+	"github.com/dusted-go/security/rng"
+)
+
+// HKDF info labels to derive independent token keys from a single master secret.
+const (
+	encryptionKeyInfo = "dusted-go/security/token/encryption"
+	signingKeyInfo    = "dusted-go/security/token/signing"
+)
+
+// checkEncryptionKey verifies that an encryption key can be used with AES.
+// An all-zero key is rejected with aes.ErrZeroKey, because token encryption
+// would fail with it later on.
+func checkEncryptionKey(encryptionKey []byte) error {
+	keyLen := len(encryptionKey)
+	if keyLen != 16 && keyLen != 24 && keyLen != 32 {
+		return fmt.Errorf("encryptionKey must be either 16, 24 or 32 bytes long. Current key length: %v", keyLen)
+	}
+	if err := aes.ValidateKey(encryptionKey); err != nil {
+		return fmt.Errorf("invalid encryptionKey: %w", err)
+	}
+	return nil
+}
+
+// checkKeys verifies that the encryption and signing keys can be used to create tokens.
+func checkKeys(encryptionKey, signingKey []byte) error {
+	if encryptionKey == nil {
+		return errors.New("encryptionKey cannot be nil")
+	}
+	if signingKey == nil {
+		return errors.New("signingKey cannot be nil")
+	}
+	return checkEncryptionKey(encryptionKey)
+}
+
+// deriveKeys derives a 256 bit encryption key and a 256 bit signing key from a master secret.
+func deriveKeys(master []byte) (encryptionKey []byte, signingKey []byte) {
+	if master == nil {
+		panic("master cannot be nil.")
+	}
+	return kdf.DeriveKey(master, nil, encryptionKeyInfo, 32),
+		kdf.DeriveKey(master, nil, signingKeyInfo, 32)
+}
+
+// GenerateKeys generates a random 256 bit encryption key and a random 256 bit signing key.
+// The keys must be stored securely and loaded on start-up. Don't generate new
+// keys per process, otherwise tokens issued by one process cannot be validated by another.
+func GenerateKeys() (encryptionKey, signingKey []byte) {
+	return rng.GenerateBytes(32), rng.GenerateBytes(32)
+}
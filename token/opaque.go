@@ -0,0 +1,39 @@
+package token
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/dusted-go/security/compare"
+	"github.com/dusted-go/security/rng"
+)
+
+// Length of an opaque token in bytes before encoding.
+const opaqueLength = 32
+
+// NewOpaque generates an opaque token: a URL-safe random 256 bit identifier
+// which carries no claims itself. The claims must be stored server-side
+// (keyed by OpaqueLookupKey), which allows tokens to be revoked instantly.
+func NewOpaque() (string, error) {
+	b := make([]byte, opaqueLength)
+	if _, err := io.ReadFull(rng.Reader, b); err != nil {
+		return "", fmt.Errorf("could not generate opaque token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// OpaqueLookupKey derives the key under which the claims of an opaque token
+// should be stored. Storing a SHA-256 hash instead of the token itself means
+// a leaked database doesn't reveal usable tokens, and timing differences of
+// the database lookup don't reveal anything about valid tokens.
+func OpaqueLookupKey(opaque string) string {
+	hash := sha256.Sum256([]byte(opaque))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// CompareOpaque compares two opaque tokens in constant time.
+func CompareOpaque(opaque1, opaque2 string) bool {
+	return compare.Hashes([]byte(opaque1), []byte(opaque2))
+}
@@ -0,0 +1,81 @@
+package token
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Marker of the optional segment which carries the kind of an encrypted token in clear,
+// e.g. "v1.k.<base64url kind>.<signature>.<cipher>". A signature never contains a dot,
+// therefore the marker cannot be confused with the signature of a token without it.
+const visibleKindMarker = "k."
+
+// visibleKindHeader returns the segment which carries a visible kind.
+func visibleKindHeader(kind string) string {
+	return visibleKindMarker + base64.RawURLEncoding.EncodeToString([]byte(kind)) + "."
+}
+
+// parseVisibleKind splits the optional visible kind from an encrypted token
+// without its version prefix and signature algorithm.
+func parseVisibleKind(token string) (header string, kind string, rest string, err error) {
+	if !strings.HasPrefix(token, visibleKindMarker) {
+		return "", "", token, nil
+	}
+	encodedKind, rest, found := strings.Cut(strings.TrimPrefix(token, visibleKindMarker), ".")
+	if !found {
+		return "", "", "", errors.New("token must consist of the kind, signature and data")
+	}
+	decodedKind, err := base64.RawURLEncoding.DecodeString(encodedKind)
+	if err != nil {
+		return "", "", "", errors.New("kind must be base64 encoded")
+	}
+	return visibleKindMarker + encodedKind + ".", string(decodedKind), rest, nil
+}
+
+// WithVisibleKind makes the Generator prepend the kind of encrypted tokens in clear,
+// so that PeekKind can read it for routing or metrics without any key.
+// The kind is covered by the signature and still encrypted in the message, so it
+// cannot be altered, but anyone who holds a token can read it. Only opt in if the
+// kind reveals nothing confidential. The Validator accepts such tokens without any
+// further configuration.
+func WithVisibleKind() GeneratorOption {
+	return func(g *Generator) {
+		g.visibleKind = true
+	}
+}
+
+// PeekKind reads the kind of an encrypted token which was generated WithVisibleKind
+// without decrypting it. The kind is NOT verified, therefore it must only be used
+// for routing or metrics. Validate verifies it together with the rest of the token.
+// An error is returned if the token doesn't carry a visible kind.
+func PeekKind(token string) (string, error) {
+	// 1. Tokens without a separator are base62 encoded
+	if !strings.Contains(token, ".") {
+		decoded, err := decodeBase62(token)
+		if err != nil {
+			return "", err
+		}
+		token = decoded
+	}
+
+	// 2. Split the format version and signature algorithm from the token
+	version, token, err := parseVersion(token)
+	if err != nil {
+		return "", err
+	}
+	token, err = version.parseAlgorithm(token)
+	if err != nil {
+		return "", err
+	}
+
+	// 3. Read the visible kind
+	header, kind, _, err := parseVisibleKind(token)
+	if err != nil {
+		return "", err
+	}
+	if header == "" {
+		return "", errors.New("token doesn't carry a visible kind")
+	}
+	return kind, nil
+}
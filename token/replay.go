@@ -0,0 +1,60 @@
+package token
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+// SeenFunc reports whether a token with the given ID has already been used.
+type SeenFunc = func(jti string) bool
+
+// MarkSeenFunc records that a token with the given ID has been used.
+// The ID can be forgotten after the expiry date of the token.
+type MarkSeenFunc = func(jti string, exp time.Time)
+
+// ErrTokenReused is returned when a one-time token has already been used.
+var ErrTokenReused = errors.New("token has already been used")
+
+// tokenID derives the ID of an encrypted token from its verified signature. Every
+// encrypted token has a random IV, therefore the signature identifies it. Signed-only
+// tokens have no IV, so tokens with the same kind, data and expiry date share a
+// signature and are not identified by it. The token string doesn't identify it either, because
+// different encodings (e.g. spare bits of base64 or leading zeros of base62) decode
+// to the same token.
+func tokenID(signature []byte) string {
+	id := sha256.Sum256(signature)
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+// WithReplayProtection makes the Validator reject tokens which have already been
+// validated successfully, e.g. for one-time password reset or email confirmation
+// tokens. The Validator doesn't own the store of used token IDs: seen looks an ID
+// up and markSeen records it together with the expiry date of the token, after
+// which it can be evicted. Signed-only tokens have no random part to tell identical
+// tokens apart, therefore they are not checked against nor marked as used. Both are called in sequence without a lock, so a store
+// which must also stop concurrent validations of the same token should insert the
+// ID atomically (set-if-absent) in seen and only set its expiry in markSeen.
+func WithReplayProtection(seen SeenFunc, markSeen MarkSeenFunc) ValidatorOption {
+	if seen == nil {
+		panic("seen cannot be nil.")
+	}
+	if markSeen == nil {
+		panic("markSeen cannot be nil.")
+	}
+	return func(v *Validator) {
+		v.seen = seen
+		v.markSeen = markSeen
+	}
+}
+
+// checkReplay rejects a valid token which has already been used and marks it as used otherwise.
+func (v *Validator) checkReplay(signature []byte, validUntil time.Time) error {
+	jti := tokenID(signature)
+	if v.seen(jti) {
+		return ErrTokenReused
+	}
+	v.markSeen(jti, validUntil)
+	return nil
+}
@@ -0,0 +1,43 @@
+package token
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dusted-go/security/rng"
+)
+
+// Kind of the tokens which carry a shared secret.
+const secretKind = "secret"
+
+// Length of a generated secret in bytes before encoding.
+const secretLength = 32
+
+// GenerateSecret generates a random URL-safe 256 bit secret and an encrypted token
+// which carries it until it expires after ttl, e.g. for a one-time download key
+// which is shared between services. The plaintext secret is handed to one party
+// and the token to the other, which recovers the secret with ValidateSecret.
+func (g *Generator) GenerateSecret(ttl time.Duration) (plaintext string, token string, err error) {
+	b := make([]byte, secretLength)
+	if _, err := io.ReadFull(rng.Reader, b); err != nil {
+		return "", "", fmt.Errorf("could not generate secret: %w", err)
+	}
+	plaintext = base64.RawURLEncoding.EncodeToString(b)
+	token, err = g.Generate(secretKind, []byte(plaintext), ttl)
+	if err != nil {
+		return "", "", err
+	}
+	return plaintext, token, nil
+}
+
+// ValidateSecret validates a token from GenerateSecret and returns its secret.
+// An error is returned after the token has expired.
+func (v *Validator) ValidateSecret(token string) (string, error) {
+	data, _, err := v.Validate(secretKind, token)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
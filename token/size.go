@@ -0,0 +1,25 @@
+package token
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// EstimateSize computes the length of an encrypted token of the given kind and
+// data length in the LatestFormat without generating one, e.g. to check that a
+// payload fits into a 4KB cookie. Base62 encoded tokens are longer.
+func EstimateSize(dataLen int, kind string) int {
+	// 1. The plain message consists of the kind, the encoded data and the expiry date,
+	// which is always formatted in UTC
+	expiryLen := len("2006-01-02T15:04:05Z")
+	plainLen := len(kind) + 1 + base64.RawURLEncoding.EncodedLen(dataLen) + 1 + expiryLen
+
+	// 2. The cipher consists of the IV and the message padded to the next full block
+	cipherLen := aes.BlockSize + (plainLen/aes.BlockSize+1)*aes.BlockSize
+
+	// 3. The token consists of the version prefix, the signature and the cipher
+	return len(LatestFormat.prefix()) +
+		base64.RawURLEncoding.EncodedLen(sha256.Size) + 1 +
+		base64.RawURLEncoding.EncodedLen(cipherLen)
+}
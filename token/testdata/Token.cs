@@ -0,0 +1,88 @@
+// Reference .NET implementation of the FormatV1 and FormatV2 tokens of
+// github.com/dusted-go/security/token, which generates the test vectors of
+// Test_Validate_WithTokenFromDotnet_ReturnsData and
+// Test_Validate_WithV2TokenFromDotnet_ReturnsData and validates tokens generated in Go.
+// Tokens with a visible kind are not implemented.
+using System;
+using System.Globalization;
+using System.Linq;
+using System.Security.Cryptography;
+using System.Text;
+
+static class Token
+{
+    static string B64Url(byte[] b) =>
+        Convert.ToBase64String(b).TrimEnd('=').Replace('+', '-').Replace('/', '_');
+
+    static byte[] FromB64Url(string s)
+    {
+        s = s.Replace('-', '+').Replace('_', '/');
+        return Convert.FromBase64String(s.PadRight(s.Length + (4 - s.Length % 4) % 4, '='));
+    }
+
+    // Version prefix which is prepended to the token and covered by the signature.
+    static string Prefix(string version) => version switch
+    {
+        "v1" => "v1.",
+        "v2" => "v2.HS256.",
+        _ => throw new Exception("unsupported version"),
+    };
+
+    public static string Generate(string version, byte[] encryptionKey, byte[] signingKey, string kind, byte[] data, DateTime expiry)
+    {
+        var message = $"{kind}.{B64Url(data)}.{expiry.ToUniversalTime().ToString("yyyy-MM-dd'T'HH:mm:ss'Z'", CultureInfo.InvariantCulture)}";
+        using var aes = Aes.Create();
+        aes.Key = encryptionKey;
+        aes.GenerateIV();
+        var encrypted = aes.EncryptCbc(Encoding.UTF8.GetBytes(message), aes.IV, PaddingMode.PKCS7);
+        var cipher = aes.IV.Concat(encrypted).ToArray();
+        using var hmac = new HMACSHA256(signingKey);
+        var signature = hmac.ComputeHash(Encoding.ASCII.GetBytes(Prefix(version)).Concat(cipher).ToArray());
+        return $"{Prefix(version)}{B64Url(signature)}.{B64Url(cipher)}";
+    }
+
+    public static string Validate(byte[] encryptionKey, byte[] signingKey, string kind, string token, DateTime now)
+    {
+        var parts = token.Split('.');
+        if (parts.Length == 4 && parts[0] == "v2" && parts[1] == "HS256") parts = new[] { "v2", parts[2], parts[3] };
+        if (parts.Length != 3 || (parts[0] != "v1" && parts[0] != "v2")) throw new Exception("invalid token");
+        var signature = FromB64Url(parts[1]);
+        var cipher = FromB64Url(parts[2]);
+        using var hmac = new HMACSHA256(signingKey);
+        var expected = hmac.ComputeHash(Encoding.ASCII.GetBytes(Prefix(parts[0])).Concat(cipher).ToArray());
+        if (!CryptographicOperations.FixedTimeEquals(signature, expected)) throw new Exception("invalid signature");
+        using var aes = Aes.Create();
+        aes.Key = encryptionKey;
+        var plain = aes.DecryptCbc(cipher.Skip(16).ToArray(), cipher.Take(16).ToArray(), PaddingMode.PKCS7);
+        var msg = Encoding.UTF8.GetString(plain).Split('.', 3);
+        if (msg[0] != kind) throw new Exception("wrong kind");
+        var expiry = DateTime.Parse(msg[2], CultureInfo.InvariantCulture, DateTimeStyles.AdjustToUniversal);
+        if (now > expiry) throw new Exception("expired");
+        return Encoding.UTF8.GetString(FromB64Url(msg[1]));
+    }
+}
+
+static class Program
+{
+    static void Main(string[] args)
+    {
+        var encryptionKey = new byte[] {
+            253, 150, 41, 236, 229, 202, 10, 148,
+            19, 143, 142, 173, 2, 221, 195, 68,
+            196, 180, 143, 219, 86, 140, 248, 46,
+            94, 222, 169, 200, 175, 219, 104, 138 };
+        var signingKey = Encoding.ASCII.GetBytes("some-stupid-secret-key");
+        var expiry = new DateTime(2023, 5, 1, 13, 0, 0, DateTimeKind.Utc);
+        // "validate <token> [<now>]" validates a token, "v1" or "v2" generates one
+        if (args.Length >= 2 && args[0] == "validate")
+        {
+            var now = args.Length == 3
+                ? DateTime.Parse(args[2], CultureInfo.InvariantCulture, DateTimeStyles.AdjustToUniversal)
+                : expiry.AddMinutes(-30);
+            Console.WriteLine(Token.Validate(encryptionKey, signingKey, "1", args[1], now));
+            return;
+        }
+        var version = args.Length == 1 ? args[0] : "v2";
+        Console.WriteLine(Token.Generate(version, encryptionKey, signingKey, "1", Encoding.UTF8.GetBytes("bla bla FOO!BAR"), expiry));
+    }
+}
@@ -1,8 +1,21 @@
 package token
 
 import (
+	"bytes"
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/dusted-go/security/aes"
+	"github.com/dusted-go/security/rng"
+	"github.com/dusted-go/security/sig"
 )
 
 func Test_RoundTrip(t *testing.T) {
@@ -38,3 +51,1092 @@ func Test_RoundTrip(t *testing.T) {
 		t.Error("Expected:", tokenData, "Actual:", string(verifiedData))
 	}
 }
+
+func Test_deriveKeys_ReturnsDistinctDeterministicKeys(t *testing.T) {
+	master := []byte("some-stupid-master-secret")
+
+	encryptionKey1, signingKey1 := deriveKeys(master)
+	encryptionKey2, signingKey2 := deriveKeys(master)
+
+	if bytes.Equal(encryptionKey1, signingKey1) {
+		t.Error("Derived encryption and signing keys were expected to differ.")
+	}
+	if !bytes.Equal(encryptionKey1, encryptionKey2) || !bytes.Equal(signingKey1, signingKey2) {
+		t.Error("Derived keys were expected to be deterministic.")
+	}
+}
+
+func Test_RoundTrip_WithMasterSecret(t *testing.T) {
+	master := []byte("some-stupid-master-secret")
+	tokenData := "bla bla FOO!BAR" // nolint
+	duration, _ := time.ParseDuration("30m")
+
+	token, err := NewGeneratorFromMaster(master).Generate("1", []byte(tokenData), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	verifiedData, _, err := NewValidatorFromMaster(master).Validate("1", token)
+	if err != nil {
+		t.Error("Unexpected error when validating token:", err.Error())
+	}
+	if string(verifiedData) != tokenData {
+		t.Error("Expected:", tokenData, "Actual:", string(verifiedData))
+	}
+}
+
+func Test_RoundTrip_WithSignedToken_ValidatesWithSigningKeyOnly(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	tokenData := "bla bla FOO!BAR" // nolint
+	duration, _ := time.ParseDuration("30m")
+
+	token, err := NewGenerator(encryptionKey, signingKey).GenerateSigned("1", []byte(tokenData), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	validator := NewSignatureValidator(signingKey)
+	verifiedData, _, err := validator.Validate("1", token)
+	if err != nil {
+		t.Error("Unexpected error when validating token:", err.Error())
+	}
+	if string(verifiedData) != tokenData {
+		t.Error("Expected:", tokenData, "Actual:", string(verifiedData))
+	}
+
+	_, _, err = NewSignatureValidator([]byte("wrong-secret-key")).Validate("1", token)
+	if err == nil {
+		t.Error("Signed token was expected to fail validation with the wrong signing key.")
+	}
+}
+
+func Test_Validate_WithSignedTokenAndEncryptingValidator_ReturnsError(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	duration, _ := time.ParseDuration("30m")
+
+	token, err := NewGenerator(encryptionKey, signingKey).GenerateSigned("1", []byte("forged"), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	data, _, err := NewValidator(encryptionKey, signingKey).Validate("1", token)
+	if err == nil {
+		t.Error("Signed token was expected to fail validation without WithSignedTokens.")
+	}
+	if data != nil {
+		t.Error("No data was expected to be returned with an error.")
+	}
+
+	data, _, err = NewValidator(encryptionKey, signingKey, WithSignedTokens()).Validate("1", token)
+	if err != nil {
+		t.Error("Unexpected error when validating token:", err.Error())
+	}
+	if string(data) != "forged" {
+		t.Error("Expected:", "forged", "Actual:", string(data))
+	}
+}
+
+func Test_Validate_WithEncryptedTokenAndSigningKeyOnly_ReturnsError(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	duration, _ := time.ParseDuration("30m")
+
+	token, err := NewGenerator(encryptionKey, signingKey).Generate("1", []byte("secret"), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	_, _, err = NewSignatureValidator(signingKey).Validate("1", token)
+	if err == nil {
+		t.Error("Encrypted token was expected to fail validation without an encryption key.")
+	}
+}
+
+func Test_RoundTrip_WithBase62Encoding_ReturnsAlphanumericToken(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	tokenData := "bla bla FOO!BAR" // nolint
+	duration, _ := time.ParseDuration("30m")
+
+	generator := NewGenerator(encryptionKey, signingKey, WithBase62Encoding())
+	token, err := generator.Generate("1", []byte(tokenData), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+	if !regexp.MustCompile("^[0-9A-Za-z]+$").MatchString(token) {
+		t.Error("Token was expected to only contain alphanumeric characters:", token)
+	}
+
+	verifiedData, _, err := NewValidator(encryptionKey, signingKey).Validate("1", token)
+	if err != nil {
+		t.Error("Unexpected error when validating token:", err.Error())
+	}
+	if string(verifiedData) != tokenData {
+		t.Error("Expected:", tokenData, "Actual:", string(verifiedData))
+	}
+}
+
+func Test_Validate_WithOversizedBase62Token_ReturnsErrorQuickly(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	token := strings.Repeat("Z", 4*1024*1024)
+
+	start := time.Now()
+	_, _, err := NewValidator(encryptionKey, signingKey).Validate("1", token)
+	if err == nil {
+		t.Error("Oversized base62 token was expected to fail validation.")
+	}
+	if _, err := PeekKind(token); err == nil {
+		t.Error("Oversized base62 token was expected to fail PeekKind.")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Error("Oversized base62 token was expected to be rejected before decoding. Elapsed:", elapsed)
+	}
+}
+
+func Test_Zeroize_OverwritesKeys(t *testing.T) {
+	encryptionKey := bytes.Repeat([]byte{7}, 32)
+	signingKey := []byte("some-stupid-secret-key")
+	validatorEncryptionKey := bytes.Repeat([]byte{7}, 32)
+	validatorSigningKey := []byte("some-stupid-secret-key")
+
+	NewGenerator(encryptionKey, signingKey).Zeroize()
+	NewValidator(validatorEncryptionKey, validatorSigningKey).Zeroize()
+
+	for _, key := range [][]byte{encryptionKey, signingKey, validatorEncryptionKey, validatorSigningKey} {
+		if !bytes.Equal(key, make([]byte, len(key))) {
+			t.Error("Key was expected to be zeroed:", key)
+		}
+	}
+}
+
+func Test_Validate_WithBadSignature_DoesNotDecrypt(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	duration, _ := time.ParseDuration("30m")
+
+	token, err := NewGenerator(encryptionKey, signingKey).Generate("1", []byte("data"), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	decryptions := 0
+	validator := NewValidator(encryptionKey, []byte("wrong-secret-key"))
+	validator.decrypt = func(key, scrambled []byte) ([]byte, error) {
+		decryptions++
+		return nil, nil
+	}
+
+	if _, _, err := validator.Validate("1", token); err == nil {
+		t.Error("Token was expected to fail validation with the wrong signing key.")
+	}
+	if decryptions != 0 {
+		t.Error("Token with a bad signature was not expected to be decrypted. Decryptions:", decryptions)
+	}
+}
+
+func Test_Validate_WithWrongKindOrExpired_ReturnsDistinctErrors(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	duration, _ := time.ParseDuration("30m")
+
+	token, err := NewGenerator(encryptionKey, signingKey).Generate("1", []byte("data"), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	validator := NewValidator(encryptionKey, signingKey)
+	_, _, kindErr := validator.Validate("2", token)
+
+	validator.now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, _, expiryErr := validator.Validate("1", token)
+
+	if kindErr == nil || expiryErr == nil {
+		t.Error("Token was expected to fail validation for the wrong kind and when expired.")
+		return
+	}
+	if kindErr.Error() == expiryErr.Error() {
+		t.Error("Wrong kind and expiry were expected to return different errors:", kindErr)
+	}
+}
+
+func Test_Validate_WithFixedClock_ExpiresExactlyAfterTTL(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	duration, _ := time.ParseDuration("30m")
+	expected := issued.Add(duration)
+
+	generator := NewGenerator(encryptionKey, signingKey, WithClock(func() time.Time { return issued }))
+	token, err := generator.Generate("1", []byte("data"), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	atExpiry := NewValidator(encryptionKey, signingKey, WithValidatorClock(func() time.Time { return expected }))
+	_, validUntil, err := atExpiry.Validate("1", token)
+	if err != nil {
+		t.Error("Token was expected to be valid until its expiry date:", err.Error())
+	}
+	if !validUntil.Equal(expected) {
+		t.Error("Expected:", expected, "Actual:", validUntil)
+	}
+
+	afterExpiry := NewValidator(encryptionKey, signingKey, WithValidatorClock(func() time.Time { return expected.Add(time.Second) }))
+	if _, _, err := afterExpiry.Validate("1", token); err == nil {
+		t.Error("Token was expected to be expired one second after its expiry date.")
+	}
+}
+
+func Test_Reissue_WithNewKeys_MovesTokenToNewKeys(t *testing.T) {
+	oldEncryptionKey, oldSigningKey := GenerateKeys()
+	newEncryptionKey, newSigningKey := GenerateKeys()
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return issued.Add(10 * time.Minute) }
+	duration, _ := time.ParseDuration("30m")
+
+	token, err := NewGenerator(oldEncryptionKey, oldSigningKey, WithClock(func() time.Time { return issued })).
+		Generate("refresh", []byte("data"), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	oldValidator := NewValidator(oldEncryptionKey, oldSigningKey, WithValidatorClock(clock))
+	newGenerator := NewGenerator(newEncryptionKey, newSigningKey, WithClock(clock))
+	reissued, err := oldValidator.Reissue("refresh", token, newGenerator)
+	if err != nil {
+		t.Error("Unexpected error when reissuing token:", err.Error())
+	}
+
+	newValidator := NewValidator(newEncryptionKey, newSigningKey, WithValidatorClock(clock))
+	data, validUntil, err := newValidator.Validate("refresh", reissued)
+	if err != nil {
+		t.Error("Reissued token was expected to be valid under the new keys:", err)
+	}
+	if string(data) != "data" {
+		t.Error("Expected:", "data", "Actual:", string(data))
+	}
+	if expected := issued.Add(duration); !validUntil.Equal(expected) {
+		t.Error("Expected:", expected, "Actual:", validUntil)
+	}
+	if _, _, err := oldValidator.Validate("refresh", reissued); err == nil {
+		t.Error("Reissued token was expected to be invalid under the old keys.")
+	}
+}
+
+func Test_Reissue_WithExpiredToken_ReturnsError(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	duration, _ := time.ParseDuration("30m")
+
+	token, err := NewGenerator(encryptionKey, signingKey, WithClock(func() time.Time { return issued })).
+		Generate("refresh", []byte("data"), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	for _, now := range []time.Time{issued.Add(duration), issued.Add(time.Hour)} {
+		now := now
+		validator := NewValidator(encryptionKey, signingKey, WithValidatorClock(func() time.Time { return now }))
+		if _, err := validator.Reissue("refresh", token, NewGenerator(encryptionKey, signingKey)); err == nil {
+			t.Error("Token was expected not to be reissued at:", now)
+		}
+	}
+}
+
+func Test_Validate_WithLegacyAndVersionedTokens_ReturnsData(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	tokenData := "bla bla FOO!BAR" // nolint
+	duration, _ := time.ParseDuration("30m")
+
+	legacyToken, err := NewGenerator(encryptionKey, signingKey, WithFormatVersion(FormatLegacy)).
+		Generate("1", []byte(tokenData), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+	versionedToken, err := NewGenerator(encryptionKey, signingKey, WithFormatVersion(FormatV1)).
+		Generate("1", []byte(tokenData), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	if strings.HasPrefix(legacyToken, "v") && strings.Index(legacyToken, ".") < 5 {
+		t.Error("Legacy token was not expected to have a version prefix:", legacyToken)
+	}
+	if !strings.HasPrefix(versionedToken, "v1.") {
+		t.Error("Token was expected to have a v1 prefix:", versionedToken)
+	}
+
+	validator := NewValidator(encryptionKey, signingKey)
+	for _, token := range []string{legacyToken, versionedToken} {
+		verifiedData, _, err := validator.Validate("1", token)
+		if err != nil {
+			t.Error("Unexpected error when validating token:", err.Error())
+		}
+		if string(verifiedData) != tokenData {
+			t.Error("Expected:", tokenData, "Actual:", string(verifiedData))
+		}
+	}
+
+	// Stripping the version prefix must not turn a v1 token into a valid legacy token
+	if _, _, err := validator.Validate("1", strings.TrimPrefix(versionedToken, "v1.")); err == nil {
+		t.Error("Downgraded token was expected to fail validation.")
+	}
+	if _, _, err := validator.Validate("1", "v9."+strings.TrimPrefix(versionedToken, "v1.")); err == nil {
+		t.Error("Token with an unsupported version was expected to fail validation.")
+	}
+}
+
+func Test_RoundTrip_WithGeneratedKeys(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	tokenData := "bla bla FOO!BAR" // nolint
+	duration, _ := time.ParseDuration("30m")
+
+	if len(encryptionKey) != 32 || len(signingKey) != 32 {
+		t.Error("Generated keys were expected to be 32 bytes long.")
+	}
+
+	token, err := NewGenerator(encryptionKey, signingKey).Generate("1", []byte(tokenData), duration)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	verifiedData, _, err := NewValidator(encryptionKey, signingKey).Validate("1", token)
+	if err != nil {
+		t.Error("Unexpected error when validating token:", err.Error())
+	}
+	if string(verifiedData) != tokenData {
+		t.Error("Expected:", tokenData, "Actual:", string(verifiedData))
+	}
+}
+
+func Test_NewGeneratorAndNewValidator_WithWrongKeyLength_Panic(t *testing.T) {
+	encryptionKey := make([]byte, 20)
+	signingKey := []byte("some-stupid-secret-key")
+
+	constructors := map[string]func(){
+		"NewGenerator": func() { NewGenerator(encryptionKey, signingKey) },
+		"NewValidator": func() { NewValidator(encryptionKey, signingKey) },
+	}
+
+	for name, construct := range constructors {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error(name, "was expected to panic for a 20 byte encryption key.")
+				}
+			}()
+			construct()
+		}()
+	}
+}
+
+func Test_NewGeneratorErrAndNewValidatorErr(t *testing.T) {
+	validKey, signingKey := GenerateKeys()
+
+	cases := []struct {
+		name          string
+		encryptionKey []byte
+		signingKey    []byte
+		expectErr     bool
+	}{
+		{"nil encryption key", nil, signingKey, true},
+		{"nil signing key", validKey, nil, true},
+		{"short encryption key", make([]byte, 15), signingKey, true},
+		{"zero encryption key", make([]byte, 32), signingKey, true},
+		{"valid keys", validKey, signingKey, false},
+	}
+
+	for _, c := range cases {
+		generator, err := NewGeneratorErr(c.encryptionKey, c.signingKey)
+		if (err != nil) != c.expectErr || (generator == nil) != c.expectErr {
+			t.Error("NewGeneratorErr returned an unexpected result for:", c.name, "Error:", err)
+		}
+
+		validator, err := NewValidatorErr(c.encryptionKey, c.signingKey)
+		if (err != nil) != c.expectErr || (validator == nil) != c.expectErr {
+			t.Error("NewValidatorErr returned an unexpected result for:", c.name, "Error:", err)
+		}
+	}
+
+	if _, err := NewGeneratorErr(make([]byte, 32), signingKey); !errors.Is(err, aes.ErrZeroKey) {
+		t.Error("Expected:", aes.ErrZeroKey, "Actual:", err)
+	}
+}
+
+func Test_NewOpaque_ReturnsUniqueTokensOfExpectedLength(t *testing.T) {
+	seen := map[string]bool{}
+
+	for i := 0; i < 100; i++ {
+		opaque, err := NewOpaque()
+		if err != nil {
+			t.Error("Unexpected error when generating opaque token:", err.Error())
+		}
+		if len(opaque) != 43 {
+			t.Error("Expected length:", 43, "Actual length:", len(opaque))
+		}
+		if seen[opaque] {
+			t.Error("Opaque tokens were expected to be unique:", opaque)
+		}
+		seen[opaque] = true
+	}
+}
+
+func Test_OpaqueLookupKeyAndCompareOpaque(t *testing.T) {
+	opaque1, _ := NewOpaque()
+	opaque2, _ := NewOpaque()
+
+	if OpaqueLookupKey(opaque1) != OpaqueLookupKey(opaque1) {
+		t.Error("Lookup key was expected to be deterministic.")
+	}
+	if OpaqueLookupKey(opaque1) == OpaqueLookupKey(opaque2) {
+		t.Error("Lookup keys of different tokens were expected to differ.")
+	}
+	if !CompareOpaque(opaque1, opaque1) || CompareOpaque(opaque1, opaque2) {
+		t.Error("CompareOpaque returned an unexpected result.")
+	}
+}
+
+func FuzzTokenValidate(f *testing.F) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	duration, _ := time.ParseDuration("30m")
+
+	generator := NewGenerator(encryptionKey, signingKey)
+	token, err := generator.Generate("1", []byte("data"), duration)
+	if err != nil {
+		f.Fatal("Unexpected error when generating token:", err.Error())
+	}
+	f.Add(token)
+	signedToken, _ := generator.GenerateSigned("1", []byte("data"), duration)
+	f.Add(signedToken)
+	base62SignedToken, _ := NewGenerator(encryptionKey, signingKey, WithBase62Encoding()).GenerateSigned("1", nil, duration)
+	f.Add(base62SignedToken)
+	f.Add("v1.")
+	f.Add("v9.a.b")
+	f.Add("s..")
+	f.Add("..")
+	f.Add("")
+
+	validator := NewValidator(encryptionKey, signingKey)
+	signatureValidator := NewSignatureValidator(signingKey)
+	f.Fuzz(func(t *testing.T, s string) {
+		// Random tokens don't get past the signature check,
+		// therefore the input is also signed to reach decryption and parsing.
+		b := []byte(s)
+		signature := base64.RawURLEncoding.EncodeToString(sig.ComputeSHA256(signingKey, FormatV1.signedMessage(b)))
+		signedSignature := base64.RawURLEncoding.EncodeToString(sig.ComputeSHA256(signingKey, signedMessage(b)))
+		encoded := base64.RawURLEncoding.EncodeToString(b)
+
+		for _, token := range []string{
+			s,
+			"v1." + signature + "." + encoded,
+			signedPrefix + signedSignature + "." + encoded,
+		} {
+			if data, _, err := validator.Validate("1", token); err != nil && data != nil {
+				t.Error("No data was expected to be returned with an error. Token:", token)
+			}
+			_, _, _ = signatureValidator.Validate("1", token)
+		}
+	})
+}
+
+func Test_GenerateClaimsAndValidateClaims_PreservesCustomClaims(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return issued }
+
+	generator := NewGenerator(encryptionKey, signingKey, WithClock(clock))
+	token, err := generator.GenerateClaims("session", Claims{
+		Subject: "user-42",
+		Custom:  map[string]any{"role": "admin", "level": 3},
+	}, time.Hour)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	validator := NewValidator(encryptionKey, signingKey, WithValidatorClock(clock))
+	claims, err := validator.ValidateClaims("session", token)
+	if err != nil {
+		t.Error("Unexpected error when validating token:", err.Error())
+	}
+	if claims.Subject != "user-42" {
+		t.Error("Expected:", "user-42", "Actual:", claims.Subject)
+	}
+	if !claims.IssuedAt.Equal(issued) {
+		t.Error("Expected:", issued, "Actual:", claims.IssuedAt)
+	}
+	if claims.Custom["role"] != "admin" || claims.Custom["level"] != float64(3) {
+		t.Error("Custom claims were expected to be preserved. Actual:", claims.Custom)
+	}
+}
+
+func Test_EstimateSize_MatchesGeneratedTokenLength(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	generator := NewGenerator(encryptionKey, signingKey)
+
+	for _, kind := range []string{"", "1", "session"} {
+		for _, dataLen := range []int{0, 1, 15, 16, 100, 1000} {
+			token, err := generator.Generate(kind, bytes.Repeat([]byte{1}, dataLen), time.Hour)
+			if err != nil {
+				t.Error("Unexpected error when generating token:", err.Error())
+			}
+			if estimate := EstimateSize(dataLen, kind); estimate != len(token) {
+				t.Error("Expected length:", len(token), "Estimated length:", estimate, "Kind:", kind, "Data length:", dataLen)
+			}
+		}
+	}
+}
+
+func Test_Generate_WithFixedRandomSourceAndClock_ReturnsIdenticalTokens(t *testing.T) {
+	original := rng.Reader
+	defer func() { rng.Reader = original }()
+
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	generator := NewGenerator(encryptionKey, signingKey, WithClock(func() time.Time { return issued }))
+
+	var tokens []string
+	for i := 0; i < 2; i++ {
+		rng.Reader = bytes.NewReader(bytes.Repeat([]byte{7}, 16))
+		token, err := generator.Generate("1", []byte("data"), time.Hour)
+		if err != nil {
+			t.Error("Unexpected error when generating token:", err.Error())
+		}
+		tokens = append(tokens, token)
+	}
+
+	if tokens[0] != tokens[1] {
+		t.Error("Tokens were expected to be identical:", tokens[0], tokens[1])
+	}
+}
+
+func Test_Validate_WithTokenFromDotnet_ReturnsData(t *testing.T) {
+	// Generated by the reference .NET implementation in testdata/Token.cs
+	// with the same keys and an expiry of 2023-05-01T13:00:00Z.
+	token := "v1.ZqadCOPJZW9RtvZZ_QfMh8GL67u0vqJHDjUj4hFNDJo.S7lJXqe8Uoh1W9bROOdvemX9L0LSTMNkGIrjbSTlfpqR0rlldWd7ICwWaHz_rYYRjYyjirOTczn5-wj1Nd1uQQ"
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	now := time.Date(2023, 5, 1, 12, 30, 0, 0, time.UTC)
+	validator := NewValidator(encryptionKey, signingKey, WithValidatorClock(func() time.Time { return now }))
+
+	verifiedData, validUntil, err := validator.Validate("1", token)
+	if err != nil {
+		t.Error("Unexpected error when validating token:", err.Error())
+	}
+	expected := "bla bla FOO!BAR"
+	if string(verifiedData) != expected {
+		t.Error("Expected:", expected, "Actual:", string(verifiedData))
+	}
+	expectedExpiry := time.Date(2023, 5, 1, 13, 0, 0, 0, time.UTC)
+	if !validUntil.Equal(expectedExpiry) {
+		t.Error("Expected:", expectedExpiry, "Actual:", validUntil)
+	}
+}
+
+func Test_Validate_WithV2TokenFromDotnet_ReturnsData(t *testing.T) {
+	// Generated by the reference .NET implementation in testdata/Token.cs
+	// with the same keys and an expiry of 2023-05-01T13:00:00Z.
+	token := "v2.HS256.5ExHNaRML83Jxa2FYIeNBW-m6k6l5oEePTfPpj7pQBc.6xXZr3Oll9fNmQwlhVPaGcgizUfN_49VC11yDYmA0aINE6McBuxfUZj75Feza92H-xTVxhv5kA9NW6u2iRR2Bg"
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	now := time.Date(2023, 5, 1, 12, 30, 0, 0, time.UTC)
+	validator := NewValidator(encryptionKey, signingKey, WithValidatorClock(func() time.Time { return now }))
+
+	verifiedData, validUntil, err := validator.Validate("1", token)
+	if err != nil {
+		t.Error("Unexpected error when validating token:", err.Error())
+	}
+	expected := "bla bla FOO!BAR"
+	if string(verifiedData) != expected {
+		t.Error("Expected:", expected, "Actual:", string(verifiedData))
+	}
+	expectedExpiry := time.Date(2023, 5, 1, 13, 0, 0, 0, time.UTC)
+	if !validUntil.Equal(expectedExpiry) {
+		t.Error("Expected:", expectedExpiry, "Actual:", validUntil)
+	}
+}
+
+func Test_Generate_WithLatestFormat_FollowsDocumentedLayout(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	generator := NewGenerator(encryptionKey, signingKey, WithClock(func() time.Time { return issued }))
+
+	token, err := generator.Generate("1", []byte("bla bla FOO!BAR"), time.Hour)
+	if err != nil {
+		t.Fatal("Unexpected error when generating token:", err.Error())
+	}
+
+	// Verify the token with the standard library only, as another implementation would
+	prefix := "v2.HS256."
+	if !strings.HasPrefix(token, prefix) {
+		t.Fatal("Token was expected to have the prefix:", prefix, "Actual:", token)
+	}
+	parts := strings.Split(strings.TrimPrefix(token, prefix), ".")
+	if len(parts) != 2 {
+		t.Fatal("Token was expected to consist of the prefix, signature and cipher:", token)
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	cipherText, _ := base64.RawURLEncoding.DecodeString(parts[1])
+
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(prefix))
+	mac.Write(cipherText)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		t.Error("Signature was expected to be HMAC-SHA256 over the prefix and cipher.")
+	}
+
+	block, _ := stdaes.NewCipher(encryptionKey)
+	plain := make([]byte, len(cipherText)-stdaes.BlockSize)
+	cipher.NewCBCDecrypter(block, cipherText[:stdaes.BlockSize]).CryptBlocks(plain, cipherText[stdaes.BlockSize:])
+	plain = plain[:len(plain)-int(plain[len(plain)-1])]
+	expected := "1." + base64.RawURLEncoding.EncodeToString([]byte("bla bla FOO!BAR")) + ".2023-05-01T13:00:00Z"
+	if string(plain) != expected {
+		t.Error("Expected:", expected, "Actual:", string(plain))
+	}
+}
+
+func Test_Validate_WithAlteredAlgorithmIdentifier_ReturnsError(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	tokenData := "bla bla FOO!BAR" // nolint
+
+	token, err := NewGenerator(encryptionKey, signingKey, WithFormatVersion(FormatV2)).
+		Generate("1", []byte(tokenData), time.Hour)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+	if !strings.HasPrefix(token, "v2.HS256.") {
+		t.Error("Token was expected to have a v2.HS256 prefix:", token)
+	}
+
+	validator := NewValidator(encryptionKey, signingKey)
+	verifiedData, _, err := validator.Validate("1", token)
+	if err != nil {
+		t.Error("Unexpected error when validating token:", err.Error())
+	}
+	if string(verifiedData) != tokenData {
+		t.Error("Expected:", tokenData, "Actual:", string(verifiedData))
+	}
+
+	rest := strings.TrimPrefix(token, "v2.HS256.")
+	for _, altered := range []string{
+		"v2.HS512." + rest,
+		"v2.hs256." + rest,
+		"v2." + rest,
+		"v1." + rest,
+	} {
+		if _, _, err := validator.Validate("1", altered); err == nil {
+			t.Error("Token with an altered algorithm identifier was expected to fail validation:", altered)
+		}
+	}
+}
+
+func Test_Generate_WithDefaultFormat_BindsAlgorithm(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+
+	token, err := NewGenerator(encryptionKey, signingKey).Generate("1", []byte("data"), time.Hour)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+	if !strings.HasPrefix(token, "v2.HS256.") {
+		t.Error("Token was expected to have a v2.HS256 prefix:", token)
+	}
+
+	// Stripping the algorithm identifier must invalidate the token
+	validator := NewValidator(encryptionKey, signingKey)
+	if _, _, err := validator.Validate("1", "v1."+strings.TrimPrefix(token, "v2.HS256.")); err == nil {
+		t.Error("Token without its algorithm identifier was expected to fail validation.")
+	}
+}
+
+func Test_Generate_DoesNotLeakKind(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	kind := "password-reset"
+
+	// The kind must neither appear in clear nor base64 encoded at any byte offset
+	var leaks []string
+	for offset := 0; offset < 3; offset++ {
+		encoded := base64.RawURLEncoding.EncodeToString(append(make([]byte, offset), kind...))
+		leaks = append(leaks, encoded[(offset*4+2)/3:len(encoded)-2])
+	}
+	leaks = append(leaks, kind)
+
+	for _, opts := range [][]GeneratorOption{
+		nil,
+		{WithFormatVersion(FormatLegacy)},
+		{WithFormatVersion(FormatV2)},
+		{WithBase62Encoding()},
+	} {
+		for i := 0; i < 10; i++ {
+			token, err := NewGenerator(encryptionKey, signingKey, opts...).Generate(kind, []byte(kind), time.Hour)
+			if err != nil {
+				t.Error("Unexpected error when generating token:", err.Error())
+			}
+			for _, leak := range leaks {
+				if strings.Contains(token, leak) {
+					t.Error("Token was expected not to contain the kind:", token, leak)
+				}
+			}
+		}
+	}
+}
+
+func Test_PeekKind_WithVisibleKind_ReturnsKindWithoutKeys(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	tokenData := "bla bla FOO!BAR" // nolint
+	validator := NewValidator(encryptionKey, signingKey)
+
+	for _, opts := range [][]GeneratorOption{
+		{WithVisibleKind()},
+		{WithVisibleKind(), WithFormatVersion(FormatLegacy)},
+		{WithVisibleKind(), WithFormatVersion(FormatV2)},
+		{WithVisibleKind(), WithBase62Encoding()},
+	} {
+		token, err := NewGenerator(encryptionKey, signingKey, opts...).Generate("password-reset", []byte(tokenData), time.Hour)
+		if err != nil {
+			t.Error("Unexpected error when generating token:", err.Error())
+		}
+
+		kind, err := PeekKind(token)
+		if err != nil {
+			t.Error("Unexpected error when peeking token:", err.Error())
+		}
+		if kind != "password-reset" {
+			t.Error("Expected:", "password-reset", "Actual:", kind)
+		}
+
+		verifiedData, _, err := validator.Validate("password-reset", token)
+		if err != nil {
+			t.Error("Unexpected error when validating token:", err.Error())
+		}
+		if string(verifiedData) != tokenData {
+			t.Error("Expected:", tokenData, "Actual:", string(verifiedData))
+		}
+	}
+}
+
+func Test_Validate_WithAlteredVisibleKind_ReturnsError(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+	validator := NewValidator(encryptionKey, signingKey)
+
+	token, err := NewGenerator(encryptionKey, signingKey, WithVisibleKind()).Generate("1", []byte("data"), time.Hour)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+	header := visibleKindHeader("1")
+	if !strings.HasPrefix(token, LatestFormat.prefix()+header) {
+		t.Error("Token was expected to carry the visible kind:", token)
+	}
+
+	for _, altered := range []string{
+		strings.Replace(token, header, visibleKindHeader("2"), 1),
+		strings.Replace(token, header, "", 1),
+	} {
+		if _, _, err := validator.Validate("1", altered); err == nil {
+			t.Error("Token with an altered visible kind was expected to fail validation:", altered)
+		}
+	}
+
+	plain, _ := NewGenerator(encryptionKey, signingKey).Generate("1", []byte("data"), time.Hour)
+	if _, err := PeekKind(plain); err == nil {
+		t.Error("PeekKind was expected to return an error for a token without a visible kind.")
+	}
+}
+
+func Test_Validate_WithReplayProtection_RejectsSecondUse(t *testing.T) {
+	encryptionKey := []byte{
+		253, 150, 41, 236, 229, 202, 10, 148,
+		19, 143, 142, 173, 2, 221, 195, 68,
+		196, 180, 143, 219, 86, 140, 248, 46,
+		94, 222, 169, 200, 175, 219, 104, 138}
+	signingKey := []byte("some-stupid-secret-key")
+
+	// In-memory store of used token IDs
+	used := map[string]time.Time{}
+	validator := NewValidator(encryptionKey, signingKey, WithReplayProtection(
+		func(jti string) bool { _, ok := used[jti]; return ok },
+		func(jti string, exp time.Time) { used[jti] = exp }))
+
+	generator := NewGenerator(encryptionKey, signingKey)
+	token, _ := generator.Generate("password-reset", []byte("user-1"), time.Hour)
+	otherToken, _ := generator.Generate("password-reset", []byte("user-1"), time.Hour)
+
+	if _, _, err := validator.Validate("password-reset", token); err != nil {
+		t.Error("Unexpected error when validating token:", err.Error())
+	}
+	if _, _, err := validator.Validate("password-reset", token); err != ErrTokenReused {
+		t.Error("Expected:", ErrTokenReused, "Actual:", err)
+	}
+	if _, _, err := validator.Validate("password-reset", otherToken); err != nil {
+		t.Error("Unexpected error when validating another token:", err.Error())
+	}
+
+	// Invalid tokens are never marked as used
+	if _, _, err := validator.Validate("session", token+"x"); err == nil {
+		t.Error("Invalid token was expected to fail validation.")
+	}
+	if len(used) != 2 {
+		t.Error("Expected:", 2, "Actual:", len(used))
+	}
+}
+
+func Test_Validate_WithReplayProtectionAndSignedToken_IsNotTracked(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return issued }
+	seenCalls := 0
+	validator := NewValidator(encryptionKey, signingKey, WithSignedTokens(), WithValidatorClock(clock), WithReplayProtection(
+		func(jti string) bool { seenCalls++; return true },
+		func(jti string, exp time.Time) { t.Error("Signed-only token was not expected to be marked as used.") }))
+
+	// Signed-only tokens with the same kind, data and expiry date are identical,
+	// therefore they can't be told apart by the replay protection
+	generator := NewGenerator(encryptionKey, signingKey, WithClock(clock))
+	token, _ := generator.GenerateSigned("newsletter", []byte("user-1"), time.Hour)
+	otherToken, _ := generator.GenerateSigned("newsletter", []byte("user-1"), time.Hour)
+	if token != otherToken {
+		t.Error("Signed-only tokens with the same content were expected to be identical.")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := validator.Validate("newsletter", token); err != nil {
+			t.Error("Unexpected error when validating token:", err.Error())
+		}
+	}
+	if seenCalls != 0 {
+		t.Error("Expected:", 0, "Actual:", seenCalls)
+	}
+}
+
+func Test_Validate_WithReplayProtectionAndReencodedToken_ReturnsErrTokenReused(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	used := map[string]time.Time{}
+	validator := NewValidator(encryptionKey, signingKey, WithReplayProtection(
+		func(jti string) bool { _, ok := used[jti]; return ok },
+		func(jti string, exp time.Time) { used[jti] = exp }))
+
+	token, _ := NewGenerator(encryptionKey, signingKey).Generate("password-reset", []byte("user-1"), time.Hour)
+	base62Token, _ := NewGenerator(encryptionKey, signingKey, WithBase62Encoding()).
+		Generate("password-reset", []byte("user-1"), time.Hour)
+
+	// Flip the spare bits of the last signature character, which decodes to the same signature
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	sigEnd := strings.LastIndex(token, ".") - 1
+	last := strings.IndexByte(alphabet, token[sigEnd])
+	flipped := token[:sigEnd] + string(alphabet[last^1]) + token[sigEnd+1:]
+	if flipped == token {
+		t.Fatal("Re-encoded token was expected to differ from the token.")
+	}
+
+	for _, reencoded := range [][2]string{
+		{token, flipped},
+		{base62Token, "0" + base62Token},
+	} {
+		if _, _, err := validator.Validate("password-reset", reencoded[0]); err != nil {
+			t.Error("Unexpected error when validating token:", err.Error())
+		}
+		if _, _, err := validator.Validate("password-reset", reencoded[1]); err != ErrTokenReused {
+			t.Error("Expected:", ErrTokenReused, "Actual:", err)
+		}
+	}
+}
+
+func Test_GenerateSecretAndValidateSecret_RoundTripsUntilExpiry(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	generator := NewGenerator(encryptionKey, signingKey, WithClock(func() time.Time { return issued }))
+	secret, token, err := generator.GenerateSecret(time.Minute)
+	if err != nil {
+		t.Error("Unexpected error when generating secret:", err.Error())
+	}
+	if len(secret) != 43 {
+		t.Error("Expected:", 43, "Actual:", len(secret))
+	}
+
+	beforeExpiry := NewValidator(encryptionKey, signingKey, WithValidatorClock(func() time.Time { return issued.Add(30 * time.Second) }))
+	actual, err := beforeExpiry.ValidateSecret(token)
+	if err != nil {
+		t.Error("Unexpected error when validating secret:", err.Error())
+	}
+	if actual != secret {
+		t.Error("Expected:", secret, "Actual:", actual)
+	}
+
+	afterExpiry := NewValidator(encryptionKey, signingKey, WithValidatorClock(func() time.Time { return issued.Add(time.Minute + time.Second) }))
+	actual, err = afterExpiry.ValidateSecret(token)
+	if err == nil || actual != "" {
+		t.Error("Expired secret token was expected to fail validation.")
+	}
+}
+
+func Test_Generate_WithTTLWithinMaxTTL_KeepsTTL(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return issued }
+
+	generator := NewGenerator(encryptionKey, signingKey, WithClock(clock), WithMaxTTL(24*time.Hour))
+	token, err := generator.Generate("session", []byte("data"), time.Hour)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	_, validUntil, _ := NewValidator(encryptionKey, signingKey, WithValidatorClock(clock)).Validate("session", token)
+	expected := issued.Add(time.Hour)
+	if !validUntil.Equal(expected) {
+		t.Error("Expected:", expected, "Actual:", validUntil)
+	}
+}
+
+func Test_Generate_WithTTLAboveMaxTTLAndClamping_ClampsTTL(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return issued }
+
+	generator := NewGenerator(encryptionKey, signingKey, WithClock(clock), WithMaxTTL(24*time.Hour), WithTTLClamping())
+	token, err := generator.Generate("session", []byte("data"), 100*365*24*time.Hour)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+
+	_, validUntil, _ := NewValidator(encryptionKey, signingKey, WithValidatorClock(clock)).Validate("session", token)
+	expected := issued.Add(24 * time.Hour)
+	if !validUntil.Equal(expected) {
+		t.Error("Expected:", expected, "Actual:", validUntil)
+	}
+}
+
+func Test_Generate_WithTTLAboveMaxTTL_ReturnsError(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+
+	generator := NewGenerator(encryptionKey, signingKey, WithMaxTTL(24*time.Hour))
+	token, err := generator.Generate("session", []byte("data"), 100*365*24*time.Hour)
+	if !errors.Is(err, ErrTTLExceeded) {
+		t.Error("Expected:", ErrTTLExceeded, "Actual:", err)
+	}
+	if token != "" {
+		t.Error("Expected empty token. Actual:", token)
+	}
+}
+
+func Test_GenerateSigned_WithTTLAboveMaxTTL_ClampsOrRejects(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return issued }
+	ttl := 100 * 365 * 24 * time.Hour
+
+	rejecting := NewGenerator(encryptionKey, signingKey, WithClock(clock), WithMaxTTL(24*time.Hour))
+	token, err := rejecting.GenerateSigned("session", []byte("data"), ttl)
+	if !errors.Is(err, ErrTTLExceeded) {
+		t.Error("Expected:", ErrTTLExceeded, "Actual:", err)
+	}
+	if token != "" {
+		t.Error("Expected empty token. Actual:", token)
+	}
+
+	clamping := NewGenerator(encryptionKey, signingKey, WithClock(clock), WithMaxTTL(24*time.Hour), WithTTLClamping())
+	token, err = clamping.GenerateSigned("session", []byte("data"), ttl)
+	if err != nil {
+		t.Error("Unexpected error when generating token:", err.Error())
+	}
+	_, validUntil, _ := NewSignatureValidator(signingKey, WithValidatorClock(clock)).Validate("session", token)
+	expected := issued.Add(24 * time.Hour)
+	if !validUntil.Equal(expected) {
+		t.Error("Expected:", expected, "Actual:", validUntil)
+	}
+}
+
+func Test_ValidateAt_WithTimeBeforeExpiry_ReturnsData(t *testing.T) {
+	encryptionKey, signingKey := GenerateKeys()
+	issued := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	generator := NewGenerator(encryptionKey, signingKey, WithClock(func() time.Time { return issued }))
+	token, _ := generator.Generate("audit", []byte("data"), time.Hour)
+	signedToken, _ := generator.GenerateSigned("audit", []byte("data"), time.Hour)
+
+	// The token has expired according to the clock of the Validator
+	validator := NewValidator(encryptionKey, signingKey, WithSignedTokens())
+	if _, _, err := validator.Validate("audit", token); err == nil {
+		t.Error("Expired token was expected to fail validation.")
+	}
+
+	for _, tkn := range []string{token, signedToken} {
+		data, err := validator.ValidateAt("audit", tkn, issued.Add(30*time.Minute))
+		if err != nil {
+			t.Error("Unexpected error when validating token:", err.Error())
+		}
+		if string(data) != "data" {
+			t.Error("Expected:", "data", "Actual:", string(data))
+		}
+
+		if _, err := validator.ValidateAt("audit", tkn, issued.Add(2*time.Hour)); err == nil {
+			t.Error("Token was expected to fail validation after its expiry.")
+		}
+	}
+}
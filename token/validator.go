@@ -3,104 +3,332 @@ package token
 import (
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/dusted-go/security/aes"
+	"github.com/dusted-go/security/compare"
+	"github.com/dusted-go/security/rng"
 	"github.com/dusted-go/security/sig"
 )
 
 // Validator can validate and decrypt a signed token.
 type Validator struct {
 	now           func() time.Time
+	decrypt       func(key, scrambled []byte) ([]byte, error)
 	encryptionKey []byte
 	signingKey    []byte
+	seen          SeenFunc
+	markSeen      MarkSeenFunc
+	acceptSigned  bool
+}
+
+// ValidatorOption configures optional behaviour of a Validator.
+type ValidatorOption = func(v *Validator)
+
+// WithValidatorClock replaces the clock of the Validator which determines whether
+// a token has expired, e.g. to test expiry boundaries without sleeping.
+// It is the Validator's counterpart of WithClock.
+func WithValidatorClock(now func() time.Time) ValidatorOption {
+	if now == nil {
+		panic("now cannot be nil.")
+	}
+	return func(v *Validator) {
+		v.now = now
+	}
+}
+
+// WithSignedTokens lets a Validator with an encryption key also accept
+// signed-only tokens from Generator.GenerateSigned.
+// Signed-only tokens can be created by anyone who holds the signing key,
+// therefore only enable it if no party outside of the service holds the key.
+func WithSignedTokens() ValidatorOption {
+	return func(v *Validator) {
+		v.acceptSigned = true
+	}
 }
 
 // NewValidator creates a new token validator.
+// It panics if the encryption key is not 16, 24 or 32 bytes long or consists of zero bytes only.
+// It rejects signed-only tokens unless WithSignedTokens is set.
 func NewValidator(
 	encryptionKey []byte,
-	signingKey []byte) *Validator {
+	signingKey []byte,
+	opts ...ValidatorOption) *Validator {
 	if encryptionKey == nil {
 		panic("encryptionKey parameter cannot be nil.")
 	}
+	if err := checkEncryptionKey(encryptionKey); err != nil {
+		panic(err.Error())
+	}
 	if signingKey == nil {
 		panic("signingKey parameter cannot be nil.")
 	}
-	return &Validator{
+	v := &Validator{
 		now:           time.Now,
+		decrypt:       aes.Decrypt,
 		encryptionKey: encryptionKey,
 		signingKey:    signingKey,
 	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// NewValidatorErr creates a new token validator like NewValidator,
+// but returns an error instead of panicking if a key is nil, has the wrong length
+// or consists of zero bytes only.
+// Use it when keys are loaded from configuration at runtime.
+func NewValidatorErr(
+	encryptionKey []byte,
+	signingKey []byte,
+	opts ...ValidatorOption) (*Validator, error) {
+	if err := checkKeys(encryptionKey, signingKey); err != nil {
+		return nil, fmt.Errorf("could not create token validator: %w", err)
+	}
+	return NewValidator(encryptionKey, signingKey, opts...), nil
+}
+
+// NewValidatorFromMaster creates a new token validator with encryption and
+// signing keys derived from a single master secret.
+func NewValidatorFromMaster(master []byte, opts ...ValidatorOption) *Validator {
+	encryptionKey, signingKey := deriveKeys(master)
+	return NewValidator(encryptionKey, signingKey, opts...)
+}
+
+// NewSignatureValidator creates a token validator which only holds the signing key.
+// It can validate tokens from Generator.GenerateSigned, e.g. for third parties
+// which must not be able to decrypt encrypted tokens.
+func NewSignatureValidator(signingKey []byte, opts ...ValidatorOption) *Validator {
+	if signingKey == nil {
+		panic("signingKey parameter cannot be nil.")
+	}
+	v := &Validator{
+		now:          time.Now,
+		decrypt:      aes.Decrypt,
+		signingKey:   signingKey,
+		acceptSigned: true,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Zeroize overwrites the encryption and signing keys with zeros.
+// The Validator keeps references to the key slices which were passed to it,
+// so callers which rely on this must not hold any other copies of the keys.
+// The Validator must not be used after it has been zeroized.
+func (v *Validator) Zeroize() {
+	rng.Zero(v.encryptionKey)
+	rng.Zero(v.signingKey)
 }
 
-// CreateValidateFunc creates a new `tokens.ValidateFunc` function.
+// Validate verifies a token of the given kind and returns its data and expiry date.
+// Signed-only tokens are only accepted by a Validator from NewSignatureValidator
+// or with WithSignedTokens.
+// With WithReplayProtection every token is only accepted once.
 func (v *Validator) Validate(kind string, token string) (verifiedData []byte, validUntil time.Time, err error) {
+	verifiedData, validUntil, signature, err := v.validate(kind, token)
+	if err != nil || v.seen == nil || signature == nil {
+		return verifiedData, validUntil, err
+	}
+	if err := v.checkReplay(signature, validUntil); err != nil {
+		return nil, time.Time{}, err
+	}
+	return verifiedData, validUntil, nil
+}
+
+// ValidateAt verifies a token of the given kind as of the given time instead of
+// the clock of the Validator, e.g. to reprocess historical audit logs with the
+// status which a token had back then. Tokens are not checked against nor marked
+// as used by WithReplayProtection.
+func (v *Validator) ValidateAt(kind string, token string, at time.Time) ([]byte, error) {
+	atValidator := *v
+	atValidator.now = func() time.Time { return at }
+	verifiedData, _, _, err := atValidator.validate(kind, token)
+	return verifiedData, err
+}
+
+// validate verifies a token of the given kind without replay protection
+// and additionally returns the verified signature of an encrypted token,
+// which is nil for signed-only tokens.
+func (v *Validator) validate(kind string, token string) (verifiedData []byte, validUntil time.Time, signature []byte, err error) {
 
 	// 1. Check that the token is not empty
 	if token == "" {
-		return nil, time.Time{}, errors.New("empty token")
+		return nil, time.Time{}, nil, errors.New("empty token")
+	}
+
+	// 2. Tokens without a separator are base62 encoded
+	if !strings.Contains(token, ".") {
+		decoded, err := decodeBase62(token)
+		if err != nil {
+			return nil, time.Time{}, nil, err
+		}
+		token = decoded
 	}
 
-	// 2. Decompose the token into the two core parts: signature and encrypted data
+	// 3. Signed-only tokens are verified but not decrypted
+	if strings.HasPrefix(token, signedPrefix) {
+		if !v.acceptSigned {
+			return nil, time.Time{}, nil, errors.New("validator does not accept signed-only tokens")
+		}
+		return v.validateSigned(kind, strings.TrimPrefix(token, signedPrefix))
+	}
+	if v.encryptionKey == nil {
+		return nil, time.Time{}, nil, errors.New("validator cannot decrypt tokens without an encryption key")
+	}
+
+	// 4. Split the format version, signature algorithm and optional visible kind from the token
+	version, token, err := parseVersion(token)
+	if err != nil {
+		return nil, time.Time{}, nil, err
+	}
+	token, err = version.parseAlgorithm(token)
+	if err != nil {
+		return nil, time.Time{}, nil, err
+	}
+	// The visible kind is covered by the signature and equals the encrypted kind,
+	// which is verified together with the other checks of the message
+	kindHeader, _, token, err := parseVisibleKind(token)
+	if err != nil {
+		return nil, time.Time{}, nil, err
+	}
+
+	// 5. Decompose the token into the two core parts: signature and encrypted data
 	expectedTokenParams := 2
 	tokenParts := strings.SplitN(token, ".", expectedTokenParams)
 	if len(tokenParts) != expectedTokenParams {
-		return nil, time.Time{}, errors.New("token must consist of two parts: signature and data")
+		return nil, time.Time{}, nil, errors.New("token must consist of two parts: signature and data")
 	}
 
-	// 3. Base64 decode the signature and data
-	signature, err := base64.RawURLEncoding.DecodeString(tokenParts[0])
+	// 6. Base64 decode the signature and data
+	signature, err = base64.RawURLEncoding.DecodeString(tokenParts[0])
 	if err != nil {
-		return nil, time.Time{}, errors.New("signature must be base64 encoded")
+		return nil, time.Time{}, nil, errors.New("signature must be base64 encoded")
 	}
 
 	cipher, err := base64.RawURLEncoding.DecodeString(tokenParts[1])
 	if err != nil {
-		return nil, time.Time{}, errors.New("data must be base64 encoded")
+		return nil, time.Time{}, nil, errors.New("data must be base64 encoded")
 	}
 
-	// 4. Validate the signature before anything else
-	if !sig.ValidateSHA256(v.signingKey, cipher, signature) {
-		return nil, time.Time{}, errors.New("signature does not match data")
+	// 7. Validate the signature before anything else
+	if !sig.ValidateSHA256(v.signingKey, version.signedMessage(append([]byte(kindHeader), cipher...)), signature) {
+		return nil, time.Time{}, nil, errors.New("signature does not match data")
 	}
 
-	// 5. Decrypt the cipher message
-	plain, err := aes.Decrypt(v.encryptionKey, cipher)
+	// 8. Decrypt the cipher message
+	plain, err := v.decrypt(v.encryptionKey, cipher)
 	if err != nil {
-		return nil, time.Time{}, errors.New("failed to decrypt data")
+		return nil, time.Time{}, nil, errors.New("failed to decrypt data")
 	}
 
-	// 6. Message consists of three parts, the token kind, data and the expiry date
+	// 9. Validate and decompose the plain message
+	verifiedData, validUntil, err = v.validateMessage(kind, plain)
+	if err != nil {
+		return nil, time.Time{}, nil, err
+	}
+	return verifiedData, validUntil, signature, nil
+}
+
+// Reissue validates a token under the Validator's keys and generates a new encrypted
+// token with the same kind, data and remaining lifetime with the given Generator.
+// It allows to migrate long-lived tokens to new keys during a key rotation.
+func (v *Validator) Reissue(kind string, token string, gen *Generator) (string, error) {
+	if gen == nil {
+		panic("gen parameter cannot be nil.")
+	}
+
+	// 1. Validate the token under the old keys
+	data, validUntil, err := v.Validate(kind, token)
+	if err != nil {
+		return "", fmt.Errorf("could not reissue token: %w", err)
+	}
+
+	// 2. Carry over the remaining lifetime, which is gone at the exact expiry date
+	ttl := validUntil.Sub(v.now().UTC())
+	if ttl <= 0 {
+		return "", errors.New("could not reissue token: token expired")
+	}
+
+	// 3. Generate the token under the new keys
+	return gen.Generate(kind, data, ttl)
+}
+
+// validateSigned verifies a signed-only token whose mode prefix has already been removed.
+// It returns no signature, so that the token is excluded from replay protection.
+func (v *Validator) validateSigned(kind string, token string) (verifiedData []byte, validUntil time.Time, signature []byte, err error) {
+
+	// 1. Decompose the token into the two core parts: signature and plain data
+	expectedTokenParams := 2
+	tokenParts := strings.SplitN(token, ".", expectedTokenParams)
+	if len(tokenParts) != expectedTokenParams {
+		return nil, time.Time{}, nil, errors.New("token must consist of two parts: signature and data")
+	}
+
+	// 2. Base64 decode the signature and data
+	signature, err = base64.RawURLEncoding.DecodeString(tokenParts[0])
+	if err != nil {
+		return nil, time.Time{}, nil, errors.New("signature must be base64 encoded")
+	}
+
+	plain, err := base64.RawURLEncoding.DecodeString(tokenParts[1])
+	if err != nil {
+		return nil, time.Time{}, nil, errors.New("data must be base64 encoded")
+	}
+
+	// 3. Validate the signature before anything else
+	if !sig.ValidateSHA256(v.signingKey, signedMessage(plain), signature) {
+		return nil, time.Time{}, nil, errors.New("signature does not match data")
+	}
+
+	// 4. Validate and decompose the plain message
+	verifiedData, validUntil, err = v.validateMessage(kind, plain)
+	if err != nil {
+		return nil, time.Time{}, nil, err
+	}
+	// The signature doesn't identify a signed-only token, see tokenID
+	return verifiedData, validUntil, nil, nil
+}
+
+// validateMessage validates the kind and expiry of a verified plain message and returns its data.
+// All checks are always evaluated before an error is returned, so that the time taken
+// doesn't reveal which of the checks failed.
+func (v *Validator) validateMessage(kind string, plain []byte) (verifiedData []byte, validUntil time.Time, err error) {
+
+	// 1. Message consists of three parts, the token kind, data and the expiry date
 	expectedMsgParams := 3
 	msgParts := strings.SplitN(string(plain), ".", expectedMsgParams)
 	if len(msgParts) != expectedMsgParams {
 		return nil, time.Time{}, errors.New("decrypted message must consist of 3 parts: token kind, data and expiry date")
 	}
 
-	// 7. Validate if the received token kind is the expected kind
+	// 2. Validate if the received token kind is the expected kind
 	// (e.g. a session token should not pass the validation for a password reset token)
 	expectedKind := msgParts[0]
-	if err != nil || kind != expectedKind {
-		return nil, time.Time{}, errors.New("token doesn't match expected kind")
-	}
+	kindOk := compare.Hashes([]byte(kind), []byte(expectedKind))
 
-	// 8. Validate the expiry of the token
-	expiry, err := time.Parse(time.RFC3339, msgParts[2])
-	if err != nil {
-		return nil, time.Time{}, errors.New("token does not include a valid expiry date")
-	}
+	// 3. Validate the expiry of the token
+	expiry, expiryErr := time.Parse(time.RFC3339, msgParts[2])
+	expired := v.now().UTC().After(expiry)
 
-	if v.now().UTC().After(expiry) {
-		return nil, time.Time{}, errors.New("token expired")
-	}
+	// 4. Base64 decode data
+	data, dataErr := base64.RawURLEncoding.DecodeString(msgParts[1])
 
-	// 9. Base64 decode data
-	data, err := base64.RawURLEncoding.DecodeString(msgParts[1])
-	if err != nil {
+	// 5. Return validated result
+	switch {
+	case !kindOk:
+		return nil, time.Time{}, errors.New("token doesn't match expected kind")
+	case expiryErr != nil:
+		return nil, time.Time{}, errors.New("token does not include a valid expiry date")
+	case expired:
+		return nil, time.Time{}, errors.New("token expired")
+	case dataErr != nil:
 		return nil, time.Time{}, errors.New("failed to base64 decode plaintext message")
 	}
-
-	// 10. Return validated result
 	return data, expiry, nil
 }